@@ -9,95 +9,334 @@ import (
 	"time"
 )
 
-func convertToType(value string, field reflect.StructField, tagOpts tagOptions) (reflect.Value, error) {
-	t := field.Type
+// ValueUnmarshaler is implemented by types that read a single CSV value with the tag options of the struct field,
+// e.g. the `format` option or custom `<key>:<value>` options. It takes precedence over encoding.TextUnmarshaler.
+type ValueUnmarshaler interface {
+	UnmarshalCSV(value string, info FieldInfo) error
+}
+
+// converterFunc converts a CSV value and sets it on the given field value.
+type converterFunc func(value string, rv reflect.Value) error
+
+// newConverter chooses the converter of the given field type once, so it can be reused for every CSV line.
+// Custom converters registered for the type take precedence over the built-in conversions.
+func newConverter(t reflect.Type, tagOpts tagOptions, custom customConverters) (converterFunc, error) {
 	if t == nil {
-		return reflect.Value{}, fmt.Errorf("invalid field provided")
+		return nil, fmt.Errorf("invalid field provided")
+	}
+	if convert, ok := custom.lookup(t); ok {
+		return convert, nil
+	}
+	if reflect.PointerTo(t).Implements(reflect.TypeOf((*ValueUnmarshaler)(nil)).Elem()) {
+		return newValueUnmarshalerConverter(custom.info), nil
 	}
-
-	var err error
-	var result interface{}
 
 	switch t.Kind() {
+	case reflect.Ptr:
+		return newPointerConverter(t, tagOpts, custom)
+	case reflect.Slice, reflect.Array:
+		return newSliceConverter(t, tagOpts, custom)
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-		result, err = strconv.ParseInt(value, 10, t.Bits())
+		return func(value string, rv reflect.Value) error {
+			result, err := strconv.ParseInt(value, 10, t.Bits())
+			if err != nil {
+				return err
+			}
+			rv.SetInt(result)
+			return nil
+		}, nil
 	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-		result, err = strconv.ParseUint(value, 10, t.Bits())
+		return func(value string, rv reflect.Value) error {
+			result, err := strconv.ParseUint(value, 10, t.Bits())
+			if err != nil {
+				return err
+			}
+			rv.SetUint(result)
+			return nil
+		}, nil
 	case reflect.Float32, reflect.Float64:
-		result, err = strconv.ParseFloat(value, t.Bits())
+		return func(value string, rv reflect.Value) error {
+			result, err := strconv.ParseFloat(value, t.Bits())
+			if err != nil {
+				return err
+			}
+			rv.SetFloat(result)
+			return nil
+		}, nil
 	case reflect.Complex64, reflect.Complex128:
-		result, err = strconv.ParseComplex(value, t.Bits())
+		return func(value string, rv reflect.Value) error {
+			result, err := strconv.ParseComplex(value, t.Bits())
+			if err != nil {
+				return err
+			}
+			rv.SetComplex(result)
+			return nil
+		}, nil
 	case reflect.Bool:
-		result, err = strconv.ParseBool(value)
+		return func(value string, rv reflect.Value) error {
+			result, err := strconv.ParseBool(value)
+			if err != nil {
+				return err
+			}
+			rv.SetBool(result)
+			return nil
+		}, nil
 	case reflect.String:
-		result = value
+		return func(value string, rv reflect.Value) error {
+			rv.SetString(value)
+			return nil
+		}, nil
 	default:
-		return convertByTypes(value, t, tagOpts)
+		return newConverterByTypes(t, tagOpts, custom)
 	}
+}
 
+// newValueUnmarshalerConverter converts empty values to the zero value and other values by UnmarshalCSV.
+func newValueUnmarshalerConverter(info FieldInfo) converterFunc {
+	return func(value string, rv reflect.Value) error {
+		if value == "" {
+			rv.Set(reflect.Zero(rv.Type()))
+			return nil
+		}
+
+		ptr := reflect.New(rv.Type())
+		if err := ptr.Interface().(ValueUnmarshaler).UnmarshalCSV(value, info); err != nil {
+			return err
+		}
+		rv.Set(ptr.Elem())
+		return nil
+	}
+}
+
+// newPointerConverter converts empty values to nil pointers and other values by the converter of the element type.
+func newPointerConverter(t reflect.Type, tagOpts tagOptions, custom customConverters) (converterFunc, error) {
+	convert, err := newConverter(t.Elem(), tagOpts, custom)
 	if err != nil {
-		return reflect.Value{}, err
+		return nil, err
 	}
 
-	return reflect.ValueOf(result).Convert(t), nil
+	return func(value string, rv reflect.Value) error {
+		if value == "" {
+			rv.Set(reflect.Zero(t))
+			return nil
+		}
+
+		ptr := reflect.New(t.Elem())
+		if err := convert(value, ptr.Elem()); err != nil {
+			return err
+		}
+		rv.Set(ptr)
+		return nil
+	}, nil
 }
 
-func convertByTypes(value string, fieldType reflect.Type, tagOpts tagOptions) (reflect.Value, error) {
-	var err error
-	var result interface{}
+// newSliceConverter splits values by the separator of the `split` option and converts every element by the converter
+// of the element type. Slices and arrays without the `split` option must implement encoding.TextUnmarshaler.
+func newSliceConverter(t reflect.Type, tagOpts tagOptions, custom customConverters) (converterFunc, error) {
+	if tagOpts.split == "" {
+		if converter, err := newConverterByTypes(t, tagOpts, custom); err == nil {
+			return converter, nil
+		}
+		return nil, fmt.Errorf("unsupported type %s, use the split tag option", t.Kind())
+	}
 
-	switch fieldType {
-	case reflect.TypeOf(time.Time{}):
-		result, err = time.Parse(tagOpts.format, value)
+	elemOpts := tagOpts
+	elemOpts.split = ""
+	convert, err := newConverter(t.Elem(), elemOpts, custom)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(value string, rv reflect.Value) error {
+		rv.Set(reflect.Zero(t))
+		if value == "" {
+			return nil
+		}
+
+		elems := strings.Split(value, tagOpts.split)
+		if t.Kind() == reflect.Array && len(elems) > t.Len() {
+			return fmt.Errorf("%d elements do not fit into an array of length %d", len(elems), t.Len())
+		}
+		if t.Kind() == reflect.Slice {
+			rv.Set(reflect.MakeSlice(t, len(elems), len(elems)))
+		}
+
+		for i, elem := range elems {
+			if err := convert(strings.TrimSpace(elem), rv.Index(i)); err != nil {
+				return fmt.Errorf("element %d: %w", i, err)
+			}
+		}
+		return nil
+	}, nil
+}
+
+// timeFormat returns the format of time.Time fields, which is time.RFC3339 if the `format` option is not set.
+func timeFormat(tagOpts tagOptions) string {
+	if tagOpts.format == "" {
+		return time.RFC3339
+	}
+	return tagOpts.format
+}
+
+func newConverterByTypes(fieldType reflect.Type, tagOpts tagOptions, custom customConverters) (converterFunc, error) {
+	switch {
+	case fieldType == reflect.TypeOf(time.Time{}):
+		format := timeFormat(tagOpts)
+		return func(value string, rv reflect.Value) error {
+			result, err := time.Parse(format, value)
+			if err != nil {
+				return err
+			}
+			rv.Set(reflect.ValueOf(result))
+			return nil
+		}, nil
+	case isSQLNullType(fieldType):
+		return newSQLNullConverter(fieldType, tagOpts, custom)
 	default:
-		return convertTextUnmarshalerType(value, fieldType)
+		return newTextUnmarshalerConverter(fieldType)
 	}
+}
+
+// isSQLNullType reports whether t is one of the nullable types of database/sql, like sql.NullString or sql.Null[T].
+// These types have the value as first field and the Valid flag as second field.
+func isSQLNullType(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct &&
+		t.PkgPath() == "database/sql" &&
+		strings.HasPrefix(t.Name(), "Null") &&
+		t.NumField() == 2 &&
+		t.Field(1).Name == "Valid" &&
+		t.Field(1).Type.Kind() == reflect.Bool
+}
 
+// newSQLNullConverter converts empty values to an invalid value and other values by the converter of the value field.
+func newSQLNullConverter(t reflect.Type, tagOpts tagOptions, custom customConverters) (converterFunc, error) {
+	convert, err := newConverter(t.Field(0).Type, tagOpts, custom)
 	if err != nil {
-		return reflect.Value{}, err
+		return nil, err
 	}
-	return reflect.ValueOf(result).Convert(fieldType), nil
-}
 
-func convertTextUnmarshalerType(value string, fieldType reflect.Type) (reflect.Value, error) {
-	targetType := determineTargetType(fieldType)
+	return func(value string, rv reflect.Value) error {
+		if value == "" {
+			rv.Set(reflect.Zero(t))
+			return nil
+		}
+
+		if err := convert(value, rv.Field(0)); err != nil {
+			return err
+		}
+		rv.Field(1).SetBool(true)
+		return nil
+	}, nil
+}
 
+func newTextUnmarshalerConverter(fieldType reflect.Type) (converterFunc, error) {
 	unmarshalerType := reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
-	if reflect.PtrTo(targetType).Implements(unmarshalerType) {
-		return handleUnmarshalerConversion(value, fieldType, targetType)
+	if !reflect.PtrTo(fieldType).Implements(unmarshalerType) {
+		return nil, fmt.Errorf("unsupported type %s", fieldType.Kind())
 	}
 
-	return reflect.Value{}, fmt.Errorf("unsupported type %s", fieldType.Kind())
+	return handleUnmarshalerConversion, nil
 }
 
-func determineTargetType(t reflect.Type) reflect.Type {
-	if t.Kind() == reflect.Ptr {
+// nullableElem returns the type of the value of pointers and database/sql nullable types, or t for other types.
+func nullableElem(t reflect.Type) reflect.Type {
+	switch {
+	case t.Kind() == reflect.Ptr:
 		return t.Elem()
+	case isSQLNullType(t):
+		return t.Field(0).Type
 	}
 	return t
 }
 
-func handleUnmarshalerConversion(value string, fieldType, targetType reflect.Type) (reflect.Value, error) {
+func handleUnmarshalerConversion(value string, rv reflect.Value) error {
 	if value == "" {
-		return handleEmptyValue(fieldType, targetType), nil
+		rv.Set(reflect.Zero(rv.Type()))
+		return nil
 	}
 
 	trimmedValue := strings.TrimSpace(value)
-	ptr := reflect.New(targetType)
+	ptr := reflect.New(rv.Type())
 
 	if err := ptr.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(trimmedValue)); err != nil {
-		return reflect.Value{}, err
+		return err
 	}
 
-	if fieldType.Kind() == reflect.Ptr {
-		return ptr, nil
+	rv.Set(ptr.Elem())
+	return nil
+}
+
+func formatValue(rv reflect.Value, tagOpts tagOptions) (string, error) {
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(rv.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(rv.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(rv.Float(), 'f', -1, rv.Type().Bits()), nil
+	case reflect.Complex64, reflect.Complex128:
+		return strconv.FormatComplex(rv.Complex(), 'f', -1, rv.Type().Bits()), nil
+	case reflect.Bool:
+		return strconv.FormatBool(rv.Bool()), nil
+	case reflect.String:
+		return rv.String(), nil
+	case reflect.Ptr:
+		if rv.IsNil() {
+			return "", nil
+		}
+		return formatValue(rv.Elem(), tagOpts)
+	case reflect.Slice, reflect.Array:
+		if tagOpts.split == "" {
+			return formatByTypes(rv, tagOpts)
+		}
+		return formatSlice(rv, tagOpts)
+	default:
+		return formatByTypes(rv, tagOpts)
 	}
-	return ptr.Elem(), nil
 }
 
-func handleEmptyValue(fieldType, targetType reflect.Type) reflect.Value {
-	if fieldType.Kind() == reflect.Struct {
-		return reflect.Zero(fieldType)
+// formatSlice joins the formatted elements with the separator of the `split` option.
+func formatSlice(rv reflect.Value, tagOpts tagOptions) (string, error) {
+	elemOpts := tagOpts
+	elemOpts.split = ""
+
+	elems := make([]string, rv.Len())
+	for i := range elems {
+		elem, err := formatValue(rv.Index(i), elemOpts)
+		if err != nil {
+			return "", err
+		}
+		elems[i] = elem
+	}
+	return strings.Join(elems, tagOpts.split), nil
+}
+
+func formatByTypes(rv reflect.Value, tagOpts tagOptions) (string, error) {
+	switch {
+	case rv.Type() == reflect.TypeOf(time.Time{}):
+		return rv.Interface().(time.Time).Format(timeFormat(tagOpts)), nil
+	case isSQLNullType(rv.Type()):
+		if !rv.Field(1).Bool() {
+			return "", nil
+		}
+		return formatValue(rv.Field(0), tagOpts)
+	default:
+		return formatTextMarshalerType(rv)
+	}
+}
+
+func formatTextMarshalerType(rv reflect.Value) (string, error) {
+	marshalerType := reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	if !rv.Type().Implements(marshalerType) && rv.CanAddr() && rv.Addr().Type().Implements(marshalerType) {
+		rv = rv.Addr()
+	}
+	if !rv.Type().Implements(marshalerType) {
+		return "", fmt.Errorf("unsupported type %s", rv.Kind())
+	}
+
+	text, err := rv.Interface().(encoding.TextMarshaler).MarshalText()
+	if err != nil {
+		return "", err
 	}
-	return reflect.Zero(reflect.PtrTo(targetType))
+	return string(text), nil
 }
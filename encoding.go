@@ -0,0 +1,242 @@
+package vcsv
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Encoding is the character encoding of CSV data.
+type Encoding int
+
+const (
+	EncodingUTF8        Encoding = iota // UTF-8, the default encoding.
+	EncodingUTF16LE                     // UTF-16 little endian, e.g. the "Unicode Text" export of Excel.
+	EncodingUTF16BE                     // UTF-16 big endian.
+	EncodingUTF32LE                     // UTF-32 little endian.
+	EncodingUTF32BE                     // UTF-32 big endian.
+	EncodingWindows1252                 // Windows-1252, the default encoding of older Windows applications in western Europe.
+	EncodingISO88591                    // ISO-8859-1 (Latin-1).
+	EncodingISO885915                   // ISO-8859-15 (Latin-9), ISO-8859-1 with the euro sign.
+)
+
+// encodingSampleSize is the number of bytes that are checked by the WithEncodingFallback option.
+const encodingSampleSize = 64 * 1024
+
+// skipBOM skips the byte order mark (BOM) at the beginning of r and returns the encoding of the BOM.
+// The returned encoding is nil if r does not start with a BOM.
+func skipBOM(r io.Reader) (io.Reader, *Encoding, error) {
+	var (
+		utf8BOM    = []byte{0xEF, 0xBB, 0xBF}
+		utf16LEBOM = []byte{0xFF, 0xFE}
+		utf16BEBOM = []byte{0xFE, 0xFF}
+		utf32LEBOM = []byte{0xFF, 0xFE, 0x00, 0x00}
+		utf32BEBOM = []byte{0x00, 0x00, 0xFE, 0xFF}
+	)
+
+	buf := make([]byte, 4)
+
+	n, err := io.ReadFull(r, buf)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return nil, nil, err
+	}
+
+	skip := func(bom []byte, encoding Encoding) (io.Reader, *Encoding, error) {
+		return io.MultiReader(bytes.NewReader(buf[len(bom):n]), r), &encoding, nil
+	}
+
+	switch {
+	case bytes.HasPrefix(buf[:n], utf32LEBOM):
+		return skip(utf32LEBOM, EncodingUTF32LE)
+	case bytes.HasPrefix(buf[:n], utf32BEBOM):
+		return skip(utf32BEBOM, EncodingUTF32BE)
+	case bytes.HasPrefix(buf[:n], utf16LEBOM):
+		return skip(utf16LEBOM, EncodingUTF16LE)
+	case bytes.HasPrefix(buf[:n], utf16BEBOM):
+		return skip(utf16BEBOM, EncodingUTF16BE)
+	case bytes.HasPrefix(buf[:n], utf8BOM):
+		return skip(utf8BOM, EncodingUTF8)
+	}
+
+	return io.MultiReader(bytes.NewReader(buf[:n]), r), nil, nil
+}
+
+// detectEncoding returns the fallback encoding if the beginning of r is not valid UTF-8, otherwise EncodingUTF8.
+// The returned reader still contains the checked bytes.
+func detectEncoding(r io.Reader, fallback Encoding) (io.Reader, Encoding, error) {
+	br := bufio.NewReaderSize(r, encodingSampleSize)
+	sample, err := br.Peek(encodingSampleSize)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, bufio.ErrBufferFull) {
+		return nil, EncodingUTF8, err
+	}
+
+	// the sample may end in the middle of a character
+	if len(sample) == encodingSampleSize {
+		for i := len(sample) - 1; i >= 0 && i >= len(sample)-utf8.UTFMax; i-- {
+			if utf8.RuneStart(sample[i]) {
+				if !utf8.FullRune(sample[i:]) {
+					sample = sample[:i]
+				}
+				break
+			}
+		}
+	}
+
+	if utf8.Valid(sample) {
+		return br, EncodingUTF8, nil
+	}
+	return br, fallback, nil
+}
+
+// newDecoder returns a reader that decodes r from the given encoding to UTF-8.
+func newDecoder(r io.Reader, encoding Encoding) io.Reader {
+	switch encoding {
+	case EncodingUTF16LE:
+		return &decodingReader{r: r, decode: decodeUTF16(binary.LittleEndian)}
+	case EncodingUTF16BE:
+		return &decodingReader{r: r, decode: decodeUTF16(binary.BigEndian)}
+	case EncodingUTF32LE:
+		return &decodingReader{r: r, decode: decodeUTF32(binary.LittleEndian)}
+	case EncodingUTF32BE:
+		return &decodingReader{r: r, decode: decodeUTF32(binary.BigEndian)}
+	case EncodingWindows1252:
+		return &decodingReader{r: r, decode: decodeSingleByte(windows1252)}
+	case EncodingISO88591:
+		return &decodingReader{r: r, decode: decodeSingleByte(iso88591)}
+	case EncodingISO885915:
+		return &decodingReader{r: r, decode: decodeSingleByte(iso885915)}
+	default:
+		return r
+	}
+}
+
+// decodeFunc appends the UTF-8 encoding of src to dst and returns the number of decoded bytes of src.
+// Incomplete characters at the end of src are not decoded, unless atEOF is set.
+type decodeFunc func(dst, src []byte, atEOF bool) ([]byte, int)
+
+// decodingReader is a reader that decodes the data of the underlying reader to UTF-8.
+type decodingReader struct {
+	r      io.Reader
+	decode decodeFunc
+	buf    [4096]byte
+	in     []byte // input that was not decoded yet
+	out    []byte // decoded output that was not read yet
+	err    error
+}
+
+func (d *decodingReader) Read(p []byte) (int, error) {
+	for len(d.out) == 0 {
+		if d.err != nil {
+			return 0, d.err
+		}
+		d.fill()
+	}
+
+	n := copy(p, d.out)
+	d.out = d.out[n:]
+	return n, nil
+}
+
+func (d *decodingReader) fill() {
+	n, err := d.r.Read(d.buf[:])
+	d.in = append(d.in, d.buf[:n]...)
+	d.err = err
+
+	var decoded int
+	d.out, decoded = d.decode(d.out[:0], d.in, err != nil)
+	d.in = d.in[:copy(d.in, d.in[decoded:])]
+}
+
+func decodeUTF16(order binary.ByteOrder) decodeFunc {
+	return func(dst, src []byte, atEOF bool) ([]byte, int) {
+		i := 0
+		for ; i+2 <= len(src); i += 2 {
+			r := rune(order.Uint16(src[i:]))
+			if utf16.IsSurrogate(r) {
+				if i+4 > len(src) {
+					if !atEOF {
+						break
+					}
+					dst = utf8.AppendRune(dst, utf8.RuneError)
+					continue
+				}
+
+				// an invalid surrogate pair only consumes its first code unit
+				r = utf16.DecodeRune(r, rune(order.Uint16(src[i+2:])))
+				if r != utf8.RuneError {
+					i += 2
+				}
+			}
+			dst = utf8.AppendRune(dst, r)
+		}
+
+		if atEOF && i < len(src) {
+			dst = utf8.AppendRune(dst, utf8.RuneError)
+			i = len(src)
+		}
+		return dst, i
+	}
+}
+
+func decodeUTF32(order binary.ByteOrder) decodeFunc {
+	return func(dst, src []byte, atEOF bool) ([]byte, int) {
+		i := 0
+		for ; i+4 <= len(src); i += 4 {
+			r := rune(order.Uint32(src[i:]))
+			if !utf8.ValidRune(r) {
+				r = utf8.RuneError
+			}
+			dst = utf8.AppendRune(dst, r)
+		}
+
+		if atEOF && i < len(src) {
+			dst = utf8.AppendRune(dst, utf8.RuneError)
+			i = len(src)
+		}
+		return dst, i
+	}
+}
+
+// singleByteTable maps every byte of a single byte encoding to its character.
+type singleByteTable [256]rune
+
+var (
+	iso88591    = newSingleByteTable(nil)
+	iso885915   = newSingleByteTable(map[byte]rune{0xA4: '€', 0xA6: 'Š', 0xA8: 'š', 0xB4: 'Ž', 0xB8: 'ž', 0xBC: 'Œ', 0xBD: 'œ', 0xBE: 'Ÿ'})
+	windows1252 = newSingleByteTable(map[byte]rune{
+		0x80: '€', 0x82: '‚', 0x83: 'ƒ', 0x84: '„', 0x85: '…', 0x86: '†', 0x87: '‡', 0x88: 'ˆ',
+		0x89: '‰', 0x8A: 'Š', 0x8B: '‹', 0x8C: 'Œ', 0x8E: 'Ž', 0x91: '‘', 0x92: '’', 0x93: '“',
+		0x94: '”', 0x95: '•', 0x96: '–', 0x97: '—', 0x98: '˜', 0x99: '™', 0x9A: 'š', 0x9B: '›',
+		0x9C: 'œ', 0x9E: 'ž', 0x9F: 'Ÿ',
+	})
+)
+
+// newSingleByteTable returns the table of ISO-8859-1, where every byte is mapped to the character with the same
+// code point, with the given characters replaced.
+func newSingleByteTable(replacements map[byte]rune) *singleByteTable {
+	var table singleByteTable
+	for i := range table {
+		table[i] = rune(i)
+	}
+	for b, r := range replacements {
+		table[b] = r
+	}
+	return &table
+}
+
+func decodeSingleByte(table *singleByteTable) decodeFunc {
+	return func(dst, src []byte, _ bool) ([]byte, int) {
+		for _, b := range src {
+			if b < utf8.RuneSelf {
+				dst = append(dst, b)
+				continue
+			}
+			dst = utf8.AppendRune(dst, table[b])
+		}
+		return dst, len(src)
+	}
+}
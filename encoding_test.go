@@ -0,0 +1,148 @@
+package vcsv
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"unicode/utf16"
+)
+
+func encodeUTF16(s string, order binary.AppendByteOrder) []byte {
+	var b []byte
+	for _, u := range utf16.Encode([]rune(s)) {
+		b = order.AppendUint16(b, u)
+	}
+	return b
+}
+
+func encodeUTF32(s string, order binary.AppendByteOrder) []byte {
+	var b []byte
+	for _, r := range s {
+		b = order.AppendUint32(b, uint32(r))
+	}
+	return b
+}
+
+func TestDecoding(t *testing.T) {
+	const text = "name,city\nJürgen,Köln 😀\n"
+
+	testCases := []struct {
+		name     string
+		input    []byte
+		encoding Encoding
+	}{
+		{"UTF-16LE BOM", append([]byte{0xFF, 0xFE}, encodeUTF16(text, binary.LittleEndian)...), EncodingUTF8},
+		{"UTF-16BE BOM", append([]byte{0xFE, 0xFF}, encodeUTF16(text, binary.BigEndian)...), EncodingUTF8},
+		{"UTF-32LE BOM", append([]byte{0xFF, 0xFE, 0x00, 0x00}, encodeUTF32(text, binary.LittleEndian)...), EncodingUTF8},
+		{"UTF-32BE BOM", append([]byte{0x00, 0x00, 0xFE, 0xFF}, encodeUTF32(text, binary.BigEndian)...), EncodingUTF8},
+		{"UTF-16LE Without BOM", encodeUTF16(text, binary.LittleEndian), EncodingUTF16LE},
+		{"UTF-32BE Without BOM", encodeUTF32(text, binary.BigEndian), EncodingUTF32BE},
+		{"BOM Overrides Encoding", append([]byte{0xEF, 0xBB, 0xBF}, text...), EncodingUTF16LE},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// read one byte at a time to split surrogate pairs and code units between reads
+			r := iotest.OneByteReader(bytes.NewReader(tc.input))
+			csvReader, err := New(r, WithEncoding(tc.encoding))
+			MustNoError(t, err)
+
+			if expect := []string{"name", "city"}; !reflect.DeepEqual(expect, csvReader.Header()) {
+				t.Fatalf("Expected header %v but got %v", expect, csvReader.Header())
+			}
+
+			csvReader.Next(&err)
+			MustNoError(t, err)
+
+			var result struct {
+				Name string `csv:"name"`
+				City string `csv:"city"`
+			}
+			MustNoError(t, csvReader.UnmarshalLine(&result))
+			if result.Name != "Jürgen" || result.City != "Köln 😀" {
+				t.Fatalf("Expected decoded values but got %+v", result)
+			}
+		})
+	}
+}
+
+func TestDecodeInvalidInput(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    []byte
+		encoding Encoding
+		expect   string
+	}{
+		{"UTF-16 Lone Surrogate", []byte{'a', 0, 0x00, 0xD8, 'b', 0}, EncodingUTF16LE, "a�b"},
+		{"UTF-16 Odd Length", []byte{'a', 0, 'b'}, EncodingUTF16LE, "a�"},
+		{"UTF-32 Invalid Rune", []byte{0, 0, 0, 'a', 0x00, 0x11, 0x00, 0x00}, EncodingUTF32BE, "a�"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := io.ReadAll(newDecoder(bytes.NewReader(tc.input), tc.encoding))
+			MustNoError(t, err)
+			if tc.expect != string(got) {
+				t.Fatalf("Expected %q but got %q", tc.expect, got)
+			}
+		})
+	}
+}
+
+func TestSingleByteDecoding(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    []byte
+		encoding Encoding
+		expect   string
+	}{
+		{"Windows-1252", []byte("K\xF6ln \x80 \x84Zitat\x93 \x81"), EncodingWindows1252, "Köln € „Zitat“ \u0081"},
+		{"ISO-8859-1", []byte("K\xF6ln \xA4 \x80"), EncodingISO88591, "Köln ¤ \u0080"},
+		{"ISO-8859-15", []byte("K\xF6ln \xA4 \xBD"), EncodingISO885915, "Köln € œ"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := io.ReadAll(newDecoder(bytes.NewReader(tc.input), tc.encoding))
+			MustNoError(t, err)
+			if tc.expect != string(got) {
+				t.Fatalf("Expected %q but got %q", tc.expect, got)
+			}
+		})
+	}
+}
+
+func TestEncodingFallback(t *testing.T) {
+	testCases := []struct {
+		name    string
+		input   []byte
+		options []Option
+		expect  string
+	}{
+		{"Valid UTF-8", []byte("city\nKöln"), []Option{WithEncodingFallback(EncodingWindows1252)}, "Köln"},
+		{"Invalid UTF-8", []byte("city\nK\xF6ln \x80"), []Option{WithEncodingFallback(EncodingWindows1252)}, "Köln €"},
+		{"Character At Sample End", append([]byte("city\n"+strings.Repeat("a", encodingSampleSize-6)), "ö"...), []Option{WithEncodingFallback(EncodingWindows1252)}, strings.Repeat("a", encodingSampleSize-6) + "ö"},
+		{"Encoding Set", []byte("city\nK\xF6ln \xA4"), []Option{WithEncoding(EncodingISO885915), WithEncodingFallback(EncodingWindows1252)}, "Köln €"},
+		{"Without Fallback", []byte("city\nK\xF6ln"), nil, "K\xF6ln"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			csvReader, err := New(bytes.NewReader(tc.input), tc.options...)
+			MustNoError(t, err)
+
+			csvReader.Next(&err)
+			MustNoError(t, err)
+
+			got, err := csvReader.Get("city")
+			MustNoError(t, err)
+			if tc.expect != got {
+				t.Fatalf("Expected %q but got %q", tc.expect, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package vcsv
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrColumnNotFound is returned when a column of a struct field is not part of the CSV header.
+	ErrColumnNotFound = errors.New("column not found")
+	// ErrIndexOutOfRange is returned when a column index of a struct field is out of range for the CSV line.
+	ErrIndexOutOfRange = errors.New("index out of range")
+	// ErrUnexpectedColumn is returned when a header column is not mapped to a struct field and the
+	// WithExtraColumns option is set to ExtraColumnsError.
+	ErrUnexpectedColumn = errors.New("unexpected column")
+	// ErrDuplicateColumn is returned when the header contains the same column name more than once and the
+	// WithDuplicateHeaders option is set to DuplicateHeadersError.
+	ErrDuplicateColumn = errors.New("duplicate column")
+	// ErrTooManyErrors is returned when more errors occurred than allowed by the WithMaxErrors option.
+	ErrTooManyErrors = errors.New("too many errors")
+)
+
+// ParseError is returned when a CSV value cannot be read into a struct field.
+// It describes where the value is located in the CSV data and which struct field it was read into.
+// Use errors.As to get the ParseError from an error returned by CSVReader.UnmarshalLine.
+type ParseError struct {
+	Line        int    // Line of the value in the CSV data, starting at 1.
+	Record      int    // Index of the data record, starting at 0. Header lines are not counted.
+	Column      string // Column name of the value, empty if the field is mapped by index.
+	ColumnIndex int    // Column index of the value, -1 if the column is not part of the header.
+	Field       string // Name of the struct field.
+	Value       string // Raw CSV value.
+	Err         error  // The conversion error.
+}
+
+func (e *ParseError) Error() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "line %d, record %d", e.Line, e.Record)
+	if e.Column != "" {
+		fmt.Fprintf(&sb, ", column \"%s\"", e.Column)
+	}
+	if e.ColumnIndex >= 0 {
+		fmt.Fprintf(&sb, ", index %d", e.ColumnIndex)
+	}
+	if e.Field != "" {
+		fmt.Fprintf(&sb, ", field %s", e.Field)
+	}
+	if e.ColumnIndex >= 0 {
+		fmt.Fprintf(&sb, ", value \"%s\"", e.Value)
+	}
+	fmt.Fprintf(&sb, ": %v", e.Err)
+	return sb.String()
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// SchemaError is returned by CSVReader.Validate when the CSV header does not match the struct.
+type SchemaError struct {
+	Missing []string // Columns of the struct that are missing in the header.
+	Extra   []string // Header columns that are not mapped to a struct field.
+}
+
+func (e *SchemaError) Error() string {
+	var parts []string
+	if len(e.Missing) > 0 {
+		parts = append(parts, fmt.Sprintf("missing columns: %s", strings.Join(e.Missing, ", ")))
+	}
+	if len(e.Extra) > 0 {
+		parts = append(parts, fmt.Sprintf("unexpected columns: %s", strings.Join(e.Extra, ", ")))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// Unwrap returns ErrColumnNotFound if columns are missing and ErrUnexpectedColumn if there are extra columns,
+// so the SchemaError can be checked with errors.Is.
+func (e *SchemaError) Unwrap() []error {
+	var errs []error
+	if len(e.Missing) > 0 {
+		errs = append(errs, ErrColumnNotFound)
+	}
+	if len(e.Extra) > 0 {
+		errs = append(errs, ErrUnexpectedColumn)
+	}
+	return errs
+}
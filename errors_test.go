@@ -0,0 +1,97 @@
+package vcsv
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestParseError(t *testing.T) {
+	type data struct {
+		Name    string `csv:"name"`
+		Comment string `csv:"comment"`
+		Age     int    `csv:"age"`
+		Missing string `csv:"missing"`
+		Third   string `csv:"index:5"`
+	}
+
+	testCases := []struct {
+		name    string
+		csvData string
+		expect  ParseError
+		target  error
+	}{
+		{
+			name:    "Conversion Error",
+			csvData: "name,comment,age\nalice,,30\nbob,\"multi\nline\",notanint",
+			expect:  ParseError{Line: 4, Record: 1, Column: "age", ColumnIndex: 2, Field: "Age", Value: "notanint"},
+			target:  strconv.ErrSyntax,
+		},
+		{
+			name:    "Missing Column",
+			csvData: "name,comment,age,other,more,last\nalice,,30,,,",
+			expect:  ParseError{Line: 2, Record: 0, Column: "missing", ColumnIndex: -1, Field: "Missing"},
+			target:  ErrColumnNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			csvReader, err := New(strings.NewReader(tc.csvData))
+			MustNoError(t, err)
+
+			var lastErr error
+			for csvReader.Next(&err) {
+				var d data
+				lastErr = csvReader.UnmarshalLine(&d)
+			}
+			MustNoError(t, err)
+
+			var parseErr *ParseError
+			if !errors.As(lastErr, &parseErr) {
+				t.Fatalf("Expected a *ParseError but got %v", lastErr)
+			}
+			if !errors.Is(lastErr, tc.target) {
+				t.Fatalf("Expected error to wrap %v but got %v", tc.target, lastErr)
+			}
+
+			parseErr.Err = nil
+			if ok := reflect.DeepEqual(tc.expect, *parseErr); !ok {
+				t.Fatalf("Expected %+v but got %+v", tc.expect, *parseErr)
+			}
+		})
+	}
+}
+
+func TestCollectErrors(t *testing.T) {
+	type data struct {
+		Name string `csv:"name"`
+		Age  int    `csv:"age"`
+		Paid bool   `csv:"paid"`
+	}
+
+	csvReader, err := New(strings.NewReader("name,age,paid\nalice,notanint,notabool"), WithCollectErrors())
+	MustNoError(t, err)
+
+	csvReader.Next(&err)
+	MustNoError(t, err)
+
+	var d data
+	err = csvReader.UnmarshalLine(&d)
+
+	joined, ok := err.(interface{ Unwrap() []error })
+	if !ok || len(joined.Unwrap()) != 2 {
+		t.Fatalf("Expected 2 joined errors but got %v", err)
+	}
+	for i, field := range []string{"Age", "Paid"} {
+		var parseErr *ParseError
+		if !errors.As(joined.Unwrap()[i], &parseErr) || parseErr.Field != field {
+			t.Fatalf("Expected error for field %s but got %v", field, joined.Unwrap()[i])
+		}
+	}
+	if d.Name != "alice" {
+		t.Fatalf("Expected valid fields to be decoded but got %+v", d)
+	}
+}
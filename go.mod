@@ -1,3 +1,3 @@
 module github.com/fond-of-vertigo/vcsv
 
-go 1.21.0
+go 1.23.0
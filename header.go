@@ -0,0 +1,195 @@
+package vcsv
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// NormalizeHeader normalizes a column name for the WithHeaderNormalizer option. It removes leading and trailing
+// whitespace, converts the name to lower case and removes spaces, underscores and dashes, so "Order ID", "order_id"
+// and " OrderId " are all normalized to "orderid".
+func NormalizeHeader(name string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) || r == '_' || r == '-' {
+			return -1
+		}
+		return unicode.ToLower(r)
+	}, name)
+}
+
+// DuplicateHeaders defines how header columns with the same name are handled.
+type DuplicateHeaders int
+
+const (
+	DuplicateHeadersKeepLast  DuplicateHeaders = iota // The name is mapped to the last column, the default.
+	DuplicateHeadersKeepFirst                         // The name is mapped to the first column.
+	DuplicateHeadersError                             // Duplicate names are reported as error.
+	DuplicateHeadersRename                            // Duplicate names get a suffix, e.g. "amount", "amount_2".
+)
+
+// indexHeader maps the normalized column names to their column indices and returns the header column names,
+// which are renamed in the DuplicateHeadersRename mode.
+func (r *CSVReader) indexHeader(columns []string) ([]string, error) {
+	header := append([]string(nil), columns...)
+	columnIndex := make(map[string]int, len(columns))
+	columnPositions := make(map[string][]int, len(columns))
+
+	var duplicates []string
+	for i, name := range columns {
+		key := r.normalizeHeader(name)
+		columnPositions[key] = append(columnPositions[key], i)
+		if len(columnPositions[key]) == 2 {
+			duplicates = append(duplicates, name)
+		}
+	}
+
+	if len(duplicates) > 0 && r.duplicateHeaders == DuplicateHeadersError {
+		return nil, fmt.Errorf("%w: %s", ErrDuplicateColumn, strings.Join(duplicates, ", "))
+	}
+
+	for i, name := range columns {
+		key := r.normalizeHeader(name)
+		positions := columnPositions[key]
+		switch {
+		case len(positions) == 1:
+			columnIndex[key] = i
+		case r.duplicateHeaders == DuplicateHeadersKeepFirst:
+			columnIndex[key] = positions[0]
+		case r.duplicateHeaders == DuplicateHeadersRename && i != positions[0]:
+			header[i] = uniqueColumnName(name, columnPositions, columnIndex, r.normalizeHeader)
+			columnIndex[r.normalizeHeader(header[i])] = i
+		case r.duplicateHeaders == DuplicateHeadersRename:
+			columnIndex[key] = i
+		default:
+			columnIndex[key] = positions[len(positions)-1]
+		}
+	}
+
+	r.columnIndex = columnIndex
+	r.columnPositions = columnPositions
+	return header, nil
+}
+
+// uniqueColumnName returns the name with the first suffix "_2", "_3", ... that is neither a column name of the header
+// nor used by a renamed column.
+func uniqueColumnName(name string, columnPositions map[string][]int, columnIndex map[string]int, normalize func(string) string) string {
+	for n := 2; ; n++ {
+		candidate := name + "_" + strconv.Itoa(n)
+		key := normalize(candidate)
+		if _, ok := columnPositions[key]; ok {
+			continue
+		}
+		if _, ok := columnIndex[key]; ok {
+			continue
+		}
+		return candidate
+	}
+}
+
+func (r *CSVReader) normalizeHeader(name string) string {
+	if r.headerNormalizer == nil {
+		return name
+	}
+	return r.headerNormalizer(name)
+}
+
+// ExtraColumns defines how Validate handles header columns that are not mapped to a struct field.
+type ExtraColumns int
+
+const (
+	ExtraColumnsIgnore ExtraColumns = iota // Extra columns are ignored, the default.
+	ExtraColumnsWarn                       // Extra columns are reported to the warning handler, see WithWarningHandler.
+	ExtraColumnsError                      // Extra columns are reported as error.
+)
+
+// Validate checks the CSV header against the `csv` tags of the given struct type, or pointer to a struct type.
+// It returns a *SchemaError that lists all columns of the struct that are missing in the header, and depending on
+// the WithExtraColumns option all header columns that are not mapped to a struct field.
+// Columns of fields with the `default` tag option are optional. Fields mapped by index are missing if the index
+// is out of range for the header, and are not checked if the reader has no header.
+func (r *CSVReader) Validate(t reflect.Type) error {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return errors.New("t must be a struct or a pointer to a struct")
+	}
+
+	p, err := r.plan(t)
+	if err != nil {
+		return err
+	}
+
+	schemaErr := &SchemaError{}
+	mapped := make(map[int]bool)
+	for _, fp := range p.fields {
+		if fp.tagOpts.metadata() {
+			continue
+		}
+		if fp.tagOpts.extra {
+			for _, column := range fp.extra {
+				mapped[column] = true
+			}
+			continue
+		}
+
+		bound := []fieldPlan{fp}
+		if fp.tagOpts.multiColumn() {
+			bound = fp.elems
+			if len(bound) == 0 && fp.tagOpts.defaultVal == nil {
+				schemaErr.Missing = append(schemaErr.Missing, "prefix:"+fp.tagOpts.prefix)
+			}
+		}
+
+		for _, fp := range bound {
+			if fp.tagOpts.columnName == "" && r.header == nil {
+				// without a header the length of the lines is not known yet
+				continue
+			}
+			if fp.column >= 0 && fp.column < len(r.header) {
+				mapped[fp.column] = true
+				continue
+			}
+			if fp.tagOpts.defaultVal != nil {
+				continue
+			}
+
+			if fp.tagOpts.columnName != "" {
+				schemaErr.Missing = append(schemaErr.Missing, fp.columnName)
+			} else {
+				schemaErr.Missing = append(schemaErr.Missing, "index:"+strconv.Itoa(fp.column))
+			}
+		}
+	}
+
+	if r.extraColumns != ExtraColumnsIgnore {
+		for i, name := range r.header {
+			if !mapped[i] {
+				schemaErr.Extra = append(schemaErr.Extra, name)
+			}
+		}
+	}
+
+	if r.extraColumns == ExtraColumnsWarn && len(schemaErr.Extra) > 0 {
+		r.warn(&SchemaError{Extra: schemaErr.Extra})
+		schemaErr.Extra = nil
+	}
+
+	if len(schemaErr.Missing) == 0 && len(schemaErr.Extra) == 0 {
+		return nil
+	}
+	return schemaErr
+}
+
+func (r *CSVReader) warn(err error) {
+	if r.warningHandler != nil {
+		r.warningHandler(err)
+		return
+	}
+	slog.Warn("vcsv: " + err.Error())
+}
@@ -0,0 +1,306 @@
+package vcsv
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeHeader(t *testing.T) {
+	for _, name := range []string{"Order ID", "order_id", " OrderId ", "ORDER-ID", "order  id"} {
+		if got := NormalizeHeader(name); got != "orderid" {
+			t.Errorf("Expected %q to be normalized to %q but got %q", name, "orderid", got)
+		}
+	}
+}
+
+func TestHeaderNormalizer(t *testing.T) {
+	type data struct {
+		OrderID  string `csv:"order_id"`
+		Customer string `csv:"Customer Name"`
+	}
+
+	header := []string{" Order ID ", "customer-name"}
+	testCases := []struct {
+		name    string
+		csvData string
+		options []Option
+	}{
+		{name: "Read Header", csvData: " Order ID ,customer-name\n42,alice", options: []Option{WithHeaderNormalizer(NormalizeHeader)}},
+		{name: "Set Header", csvData: "42,alice", options: []Option{WithHeader(header), WithHeaderNormalizer(NormalizeHeader)}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			csvReader, err := New(strings.NewReader(tc.csvData), tc.options...)
+			MustNoError(t, err)
+
+			if ok := reflect.DeepEqual(header, csvReader.Header()); !ok {
+				t.Fatalf("Expected header %v but got %v", header, csvReader.Header())
+			}
+
+			csvReader.Next(&err)
+			MustNoError(t, err)
+
+			var result data
+			MustNoError(t, csvReader.UnmarshalLine(&result))
+			if expect := (data{OrderID: "42", Customer: "alice"}); expect != result {
+				t.Fatalf("Expected %+v but got %+v", expect, result)
+			}
+
+			value, err := csvReader.Get("OrderId")
+			MustNoError(t, err)
+			if value != "42" {
+				t.Fatalf("Expected %q but got %q", "42", value)
+			}
+		})
+	}
+}
+
+func TestHeaderNormalizerPrefix(t *testing.T) {
+	type data struct {
+		Weeks []int `csv:"prefix:week_"`
+	}
+
+	for _, normalize := range []func(string) string{NormalizeHeader, strings.ToUpper} {
+		csvReader, err := New(strings.NewReader("Name,Week_1,Week_2\nalice,1,2"), WithHeaderNormalizer(normalize))
+		MustNoError(t, err)
+
+		csvReader.Next(&err)
+		MustNoError(t, err)
+
+		var result data
+		MustNoError(t, csvReader.UnmarshalLine(&result))
+		if expect := []int{1, 2}; !reflect.DeepEqual(expect, result.Weeks) {
+			t.Fatalf("Expected %v but got %v", expect, result.Weeks)
+		}
+	}
+}
+
+func TestAlternativeColumnNames(t *testing.T) {
+	type data struct {
+		SKU      string `csv:"sku|article_no|ItemNumber"`
+		Quantity int    `csv:"quantity"`
+	}
+
+	testCases := []struct {
+		name      string
+		csvData   string
+		expectErr bool
+	}{
+		{name: "First Name", csvData: "sku,quantity\nA-1,2"},
+		{name: "Second Name", csvData: "article_no,quantity\nA-1,2"},
+		{name: "Third Name", csvData: "quantity,ItemNumber\n2,A-1"},
+		{name: "Tag Order Wins", csvData: "ItemNumber,article_no,quantity\nB-2,A-1,2"},
+		{name: "No Name", csvData: "article,quantity\nA-1,2", expectErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			csvReader, err := New(strings.NewReader(tc.csvData))
+			MustNoError(t, err)
+
+			csvReader.Next(&err)
+			MustNoError(t, err)
+
+			var result data
+			err = csvReader.UnmarshalLine(&result)
+			if tc.expectErr {
+				var parseErr *ParseError
+				if !errors.As(err, &parseErr) || !errors.Is(err, ErrColumnNotFound) || parseErr.Column != "sku|article_no|ItemNumber" {
+					t.Fatalf("Expected column not found error but got %v", err)
+				}
+				return
+			}
+
+			MustNoError(t, err)
+			if expect := (data{SKU: "A-1", Quantity: 2}); expect != result {
+				t.Fatalf("Expected %+v but got %+v", expect, result)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	type data struct {
+		Name    string `csv:"name"`
+		Age     int    `csv:"age"`
+		City    string `csv:"city"`
+		Country string `csv:"country,default:DE"`
+		Level   int    `csv:"index:4"`
+	}
+	type multiColumn struct {
+		Sales []int `csv:"index:1-2"`
+		Weeks []int `csv:"prefix:week_"`
+	}
+	type withExtra struct {
+		Name  string            `csv:"name"`
+		Extra map[string]string `csv:",extra"`
+	}
+
+	testCases := []struct {
+		name         string
+		csvData      string
+		v            any
+		mode         ExtraColumns
+		expect       *SchemaError
+		expectWarned []string
+	}{
+		{name: "Valid Header", csvData: "name,age,city,country,level"},
+		{name: "Optional Column", csvData: "name,age,city,level,level"},
+		{name: "Missing Columns", csvData: "name,other", expect: &SchemaError{Missing: []string{"age", "city", "index:4"}}},
+		{name: "Extra Columns Ignored", csvData: "name,age,city,zip,level,comment"},
+		{name: "Extra Columns Error", csvData: "name,age,city,zip,level,comment", mode: ExtraColumnsError, expect: &SchemaError{Extra: []string{"zip", "comment"}}},
+		{name: "Extra Columns Warn", csvData: "name,age,city,zip,level,comment", mode: ExtraColumnsWarn, expectWarned: []string{"zip", "comment"}},
+		{name: "Missing And Extra Columns", csvData: "name,zip", mode: ExtraColumnsError, expect: &SchemaError{Missing: []string{"age", "city", "index:4"}, Extra: []string{"zip"}}},
+		{name: "Multi Column Fields", csvData: "name,a,b,week_1,week_2", v: &multiColumn{}, mode: ExtraColumnsError, expect: &SchemaError{Extra: []string{"name"}}},
+		{name: "Extra Field", csvData: "name,zip,comment", v: &withExtra{}, mode: ExtraColumnsError},
+		{name: "Missing Multi Column Fields", csvData: "name,a", v: &multiColumn{}, expect: &SchemaError{Missing: []string{"index:2", "prefix:week_"}}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var warned []string
+			csvReader, err := New(strings.NewReader(tc.csvData), WithExtraColumns(tc.mode), WithWarningHandler(func(err error) {
+				var schemaErr *SchemaError
+				if errors.As(err, &schemaErr) {
+					warned = append(warned, schemaErr.Extra...)
+				}
+			}))
+			MustNoError(t, err)
+
+			v := tc.v
+			if v == nil {
+				v = &data{}
+			}
+			err = csvReader.Validate(reflect.TypeOf(v))
+			if tc.expect == nil {
+				MustNoError(t, err)
+			} else {
+				var schemaErr *SchemaError
+				if !errors.As(err, &schemaErr) || !reflect.DeepEqual(tc.expect, schemaErr) {
+					t.Fatalf("Expected %+v but got %v", tc.expect, err)
+				}
+			}
+
+			if !reflect.DeepEqual(tc.expectWarned, warned) {
+				t.Fatalf("Expected warnings for %v but got %v", tc.expectWarned, warned)
+			}
+		})
+	}
+}
+
+func TestValidateWithoutHeader(t *testing.T) {
+	type data struct {
+		Name  string `csv:"index:0"`
+		Sales []int  `csv:"index:1-2"`
+		City  string `csv:"city"`
+	}
+
+	csvReader, err := New(strings.NewReader("alice,1,2"), WithReadHeader(-1))
+	MustNoError(t, err)
+
+	err = csvReader.Validate(reflect.TypeOf(data{}))
+	var schemaErr *SchemaError
+	if expect := (&SchemaError{Missing: []string{"city"}}); !errors.As(err, &schemaErr) || !reflect.DeepEqual(expect, schemaErr) {
+		t.Fatalf("Expected %+v but got %v", expect, err)
+	}
+}
+
+func TestNewReaderValidatesHeader(t *testing.T) {
+	_, err := NewReader[typedTestStruct](strings.NewReader("name,city\nalice,Köln"))
+	if !errors.Is(err, ErrColumnNotFound) {
+		t.Fatalf("Expected missing column error but got %v", err)
+	}
+}
+
+func TestDuplicateHeaders(t *testing.T) {
+	type data struct {
+		Amount       string `csv:"amount"`
+		SecondAmount string `csv:"amount,occurrence:2"`
+	}
+
+	const csvData = "amount,amount_2,amount,AMOUNT\n1,2,3,4"
+
+	testCases := []struct {
+		name         string
+		options      []Option
+		expect       data
+		expectHeader []string
+		expectErr    bool
+	}{
+		{
+			name:         "Keep Last",
+			expect:       data{Amount: "3", SecondAmount: "3"},
+			expectHeader: []string{"amount", "amount_2", "amount", "AMOUNT"},
+		},
+		{
+			name:         "Keep First",
+			options:      []Option{WithDuplicateHeaders(DuplicateHeadersKeepFirst)},
+			expect:       data{Amount: "1", SecondAmount: "3"},
+			expectHeader: []string{"amount", "amount_2", "amount", "AMOUNT"},
+		},
+		{
+			name:         "Rename",
+			options:      []Option{WithDuplicateHeaders(DuplicateHeadersRename), WithHeaderNormalizer(strings.ToLower)},
+			expect:       data{Amount: "1", SecondAmount: "3"},
+			expectHeader: []string{"amount", "amount_2", "amount_3", "AMOUNT_4"},
+		},
+		{
+			name:      "Error",
+			options:   []Option{WithDuplicateHeaders(DuplicateHeadersError)},
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			csvReader, err := New(strings.NewReader(csvData), tc.options...)
+			if tc.expectErr {
+				if !errors.Is(err, ErrDuplicateColumn) {
+					t.Fatalf("Expected duplicate column error but got %v", err)
+				}
+				return
+			}
+			MustNoError(t, err)
+
+			if !reflect.DeepEqual(tc.expectHeader, csvReader.Header()) {
+				t.Fatalf("Expected header %v but got %v", tc.expectHeader, csvReader.Header())
+			}
+
+			csvReader.Next(&err)
+			MustNoError(t, err)
+
+			var result data
+			MustNoError(t, csvReader.UnmarshalLine(&result))
+			if tc.expect != result {
+				t.Fatalf("Expected %+v but got %+v", tc.expect, result)
+			}
+		})
+	}
+}
+
+func TestSetHeaderDuplicateError(t *testing.T) {
+	type data struct {
+		Name string `csv:"name"`
+	}
+
+	csvReader, err := New(strings.NewReader("alice,bob"), WithHeader([]string{"name", "other"}), WithDuplicateHeaders(DuplicateHeadersError))
+	MustNoError(t, err)
+
+	csvReader.SetHeader([]string{"name", "name"})
+	csvReader.Next(&err)
+	MustNoError(t, err)
+
+	var result data
+	if err := csvReader.UnmarshalLine(&result); !errors.Is(err, ErrDuplicateColumn) {
+		t.Fatalf("Expected duplicate column error but got %v", err)
+	}
+	if err := csvReader.Validate(reflect.TypeOf(result)); !errors.Is(err, ErrDuplicateColumn) {
+		t.Fatalf("Expected duplicate column error but got %v", err)
+	}
+
+	csvReader.SetHeader([]string{"name", "other"})
+	MustNoError(t, csvReader.UnmarshalLine(&result))
+}
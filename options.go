@@ -1,19 +1,108 @@
 package vcsv
 
+import "reflect"
+
 type Option func(*CSVReader)
 
 // WithHeader sets the CSV header columns.
 func WithHeader(columns []string) Option {
 	return func(r *CSVReader) {
 		r.headerAtLine = -1
-		r.SetHeader(columns)
+		r.headerSet = true
+		r.header = columns
+	}
+}
+
+// WithConverter sets a converter for the type T, that takes precedence over RegisterConverter and the built-in
+// conversions of T, including time.Time and encoding.TextUnmarshaler. The converter gets the tag options of the field.
+func WithConverter[T any](convert func(value string, info FieldInfo) (T, error)) Option {
+	return func(r *CSVReader) {
+		if r.converters == nil {
+			r.converters = make(map[reflect.Type]registeredConverter)
+		}
+		r.converters[typeOf[T]()] = newRegisteredConverter(convert)
+	}
+}
+
+// WithHeaderNormalizer sets a function that normalizes the CSV header column names and the column names of the
+// struct tags before they are compared, e.g. strings.ToLower or NormalizeHeader.
+func WithHeaderNormalizer(normalize func(string) string) Option {
+	return func(r *CSVReader) {
+		r.headerNormalizer = normalize
+	}
+}
+
+// WithDuplicateHeaders sets how header columns with the same name are handled. Use the `occurrence` tag option to
+// map a struct field to a specific column of a duplicate name, regardless of this option.
+//
+// The default value is DuplicateHeadersKeepLast.
+func WithDuplicateHeaders(mode DuplicateHeaders) Option {
+	return func(r *CSVReader) {
+		r.duplicateHeaders = mode
+	}
+}
+
+// WithExtraColumns sets how CSVReader.Validate handles header columns that are not mapped to a struct field.
+//
+// The default value is ExtraColumnsIgnore.
+func WithExtraColumns(mode ExtraColumns) Option {
+	return func(r *CSVReader) {
+		r.extraColumns = mode
+	}
+}
+
+// WithWarningHandler sets the function that is called with warnings, e.g. extra columns in the ExtraColumnsWarn mode.
+// By default, warnings are logged with slog.Warn.
+func WithWarningHandler(handler func(error)) Option {
+	return func(r *CSVReader) {
+		r.warningHandler = handler
 	}
 }
 
 // WithSeparationChar sets the CSV separation character.
 func WithSeparationChar(separationChar rune) Option {
 	return func(r *CSVReader) {
-		r.reader.Comma = separationChar
+		r.separationChar = separationChar
+	}
+}
+
+// WithDialect sets the separation character and whether the CSV data has a header, as detected by Sniff.
+// If the dialect has no header, the header is not read and fields must be mapped by index.
+func WithDialect(dialect Dialect) Option {
+	return func(r *CSVReader) {
+		r.separationChar = dialect.Separator
+		if !dialect.HasHeader {
+			r.headerAtLine = -1
+			r.headerSet = true
+		}
+	}
+}
+
+// WithAutoDetect detects the separation character and whether the CSV data has a header by looking at the first lines,
+// see Sniff. The WithSeparationChar, WithHeader and WithReadHeader options take precedence over the detected values.
+func WithAutoDetect() Option {
+	return func(r *CSVReader) {
+		r.autoDetect = true
+	}
+}
+
+// WithEncoding sets the encoding of the CSV data, which is decoded to UTF-8 before it is read.
+// The encoding is only used if the CSV data does not start with a byte order mark (BOM),
+// otherwise the encoding of the BOM is used.
+//
+// The default value is EncodingUTF8.
+func WithEncoding(encoding Encoding) Option {
+	return func(r *CSVReader) {
+		r.encoding = encoding
+	}
+}
+
+// WithEncodingFallback sets the encoding that is used if the CSV data is not valid UTF-8, typically EncodingWindows1252.
+// Only the first 64 KiB are checked, and only if the CSV data does not start with a byte order mark (BOM)
+// and no other encoding was set by the WithEncoding option.
+func WithEncodingFallback(encoding Encoding) Option {
+	return func(r *CSVReader) {
+		r.encodingFallback = &encoding
 	}
 }
 
@@ -25,5 +114,63 @@ func WithSeparationChar(separationChar rune) Option {
 func WithReadHeader(line int) Option {
 	return func(r *CSVReader) {
 		r.headerAtLine = line
+		r.headerSet = true
+	}
+}
+
+// WithCollectErrors makes UnmarshalLine decode all fields of a line, even if some of them fail.
+// The errors of all failed fields are returned together, joined by errors.Join.
+// Reader.ReadAll also collects the errors of all lines instead of stopping at the first one.
+func WithCollectErrors() Option {
+	return func(r *CSVReader) {
+		r.collectErrors = true
+	}
+}
+
+// WithMaxErrors sets the maximum number of lines with errors that Reader.ReadAll collects when the
+// WithCollectErrors option is set. If another line fails, reading stops with ErrTooManyErrors.
+// If the value is 0 or negative, all errors are collected.
+//
+// The default value is 0.
+func WithMaxErrors(n int) Option {
+	return func(r *CSVReader) {
+		r.maxErrors = n
+	}
+}
+
+// WithNullValues sets the CSV values that are treated like empty values, e.g. "NULL", "\\N" or "N/A".
+// Nullable fields are set to nil, an invalid sql.Null* value or the zero value, or to their default value
+// if the `default` tag option is set. The `null` tag option overrides the null values for a single field.
+func WithNullValues(values ...string) Option {
+	return func(r *CSVReader) {
+		r.nullValues = values
+	}
+}
+
+type WriterOption func(*CSVWriter)
+
+// WithWriterHeader sets the CSV header columns written by the CSVWriter. Fields tagged with a column name
+// are written to the column with the same name, instead of the order of the struct fields.
+func WithWriterHeader(columns []string) WriterOption {
+	return func(w *CSVWriter) {
+		w.header = columns
+		w.headerIndex = make(map[string]int)
+		for i, name := range columns {
+			w.headerIndex[name] = i
+		}
+	}
+}
+
+// WithWriterSeparationChar sets the CSV separation character of the CSVWriter.
+func WithWriterSeparationChar(separationChar rune) WriterOption {
+	return func(w *CSVWriter) {
+		w.writer.Comma = separationChar
+	}
+}
+
+// WithoutHeader disables writing the CSV header before the first line.
+func WithoutHeader() WriterOption {
+	return func(w *CSVWriter) {
+		w.writeHeader = false
 	}
 }
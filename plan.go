@@ -0,0 +1,406 @@
+package vcsv
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// structPlan is the compiled decoding plan of a struct type for the current header.
+// It is built once per struct type and header, so tags are not parsed and column names are not
+// looked up again for every CSV line.
+type structPlan struct {
+	fields []fieldPlan
+}
+
+// fieldPlan describes how a single struct field is decoded.
+type fieldPlan struct {
+	taggedField
+	columnName string // resolved column name, all alternatives if none is part of the header
+	column     int    // resolved column index, -1 if the column is not part of the header
+	nullValues []string
+	convert    converterFunc
+	validators []validatorFunc
+	elems      []fieldPlan // plans of the elements of a slice field bound to a column range or to repeated columns
+	extra      []int       // header columns of a map field with the extra option, that are not bound to other fields
+}
+
+// plan returns the cached plan of the given struct type or compiles it.
+// The cache is reset whenever the header changes. An error of the header, like duplicate columns, is returned instead.
+func (r *CSVReader) plan(rt reflect.Type) (*structPlan, error) {
+	if r.headerErr != nil {
+		return nil, r.headerErr
+	}
+	if p, ok := r.plans[rt]; ok {
+		return p, nil
+	}
+
+	fields, err := cachedTaggedFields(rt)
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := r.compilePlan(fields)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.plans == nil {
+		r.plans = make(map[reflect.Type]*structPlan)
+	}
+	r.plans[rt] = p
+	return p, nil
+}
+
+func (r *CSVReader) compilePlan(fields []taggedField) (*structPlan, error) {
+	p := &structPlan{fields: make([]fieldPlan, 0, len(fields))}
+	for _, f := range fields {
+		fp := fieldPlan{taggedField: f, column: -1, nullValues: r.nullValues}
+		if f.tagOpts.nullValues != nil {
+			fp.nullValues = f.tagOpts.nullValues
+		}
+		switch {
+		case f.tagOpts.extra:
+			if err := validateExtraType(f); err != nil {
+				return nil, err
+			}
+			p.fields = append(p.fields, fp)
+			continue
+		case f.tagOpts.metadata():
+			// metadata fields are converted like column values, see fieldValue
+		case f.tagOpts.multiColumn():
+			if err := r.compileElems(&fp); err != nil {
+				return nil, err
+			}
+			p.fields = append(p.fields, fp)
+			continue
+		case f.tagOpts.columnName != "":
+			fp.columnName, fp.column = r.resolveColumn(f.tagOpts.columnNames, f.tagOpts.occurrence)
+		case f.tagOpts.index >= 0:
+			fp.column = f.tagOpts.index
+		default:
+			continue
+		}
+
+		if err := r.compileField(&fp, f.structField.Type); err != nil {
+			return nil, err
+		}
+		p.fields = append(p.fields, fp)
+	}
+
+	r.bindExtraColumns(p)
+	return p, nil
+}
+
+// customConverters returns the custom converters of the reader with the info of the given field.
+func (r *CSVReader) customConverters(fp *fieldPlan) customConverters {
+	return customConverters{
+		converters: r.converters,
+		info: FieldInfo{
+			Name:    fp.name,
+			Column:  fp.columnName,
+			Index:   fp.column,
+			Format:  fp.tagOpts.format,
+			Options: maps.Clone(fp.tagOpts.custom),
+		},
+	}
+}
+
+// validateExtraType checks that a field with the extra option is a map[string]string or map[string]any.
+func validateExtraType(f taggedField) error {
+	t := f.structField.Type
+	if t.Kind() != reflect.Map || t.Key().Kind() != reflect.String ||
+		(t.Elem().Kind() != reflect.String && t.Elem() != reflect.TypeOf((*any)(nil)).Elem()) {
+		return fmt.Errorf("failed to read field %s [%s]: the extra option requires a map[string]string or map[string]any field", f.name, f.structField.Tag)
+	}
+	return nil
+}
+
+// bindExtraColumns binds all header columns that are not bound to another field to the fields with the extra option.
+func (r *CSVReader) bindExtraColumns(p *structPlan) {
+	bound := make(map[int]bool)
+	for _, fp := range p.fields {
+		bound[fp.column] = true
+		for _, elem := range fp.elems {
+			bound[elem.column] = true
+		}
+	}
+
+	for i := range p.fields {
+		if !p.fields[i].tagOpts.extra {
+			continue
+		}
+		for column := range r.header {
+			if !bound[column] {
+				p.fields[i].extra = append(p.fields[i].extra, column)
+			}
+		}
+	}
+}
+
+// compileField chooses the converter and validators of a field, or of an element of a multi-column field.
+func (r *CSVReader) compileField(fp *fieldPlan, t reflect.Type) error {
+	custom := r.customConverters(fp)
+	if len(fp.tagOpts.custom) > 0 && !custom.readsOptions(t) {
+		return fmt.Errorf("failed to read field %s [%s]: unknown tag option \"%s\"", fp.name, fp.structField.Tag, firstOption(fp.tagOpts.custom))
+	}
+
+	convert, err := newConverter(t, fp.tagOpts, custom)
+	if err != nil {
+		return fmt.Errorf("failed to read field %s [%s]: %w", fp.name, fp.structField.Tag, err)
+	}
+	fp.convert = convert
+
+	if fp.tagOpts.defaultVal != nil {
+		if err := convert(*fp.tagOpts.defaultVal, reflect.New(t).Elem()); err != nil {
+			return fmt.Errorf("invalid default value of field %s [%s]: %w", fp.name, fp.structField.Tag, err)
+		}
+	}
+
+	fp.validators, err = newValidators(t, fp.tagOpts)
+	if err != nil {
+		return fmt.Errorf("failed to read field %s [%s]: %w", fp.name, fp.structField.Tag, err)
+	}
+	return nil
+}
+
+// compileElems compiles a plan for every column of a slice or array field bound to a column range or to the
+// columns with a prefix. Every element is decoded like a field of its own, so null values, default values and
+// validation tag options apply to the single elements.
+func (r *CSVReader) compileElems(fp *fieldPlan) error {
+	t := fp.structField.Type
+	if t.Kind() != reflect.Slice && t.Kind() != reflect.Array {
+		return fmt.Errorf("failed to read field %s [%s]: column ranges and prefixes require a slice or array field", fp.name, fp.structField.Tag)
+	}
+
+	var columns []int
+	if fp.tagOpts.prefix != "" {
+		fp.columnName = fp.tagOpts.prefix
+		prefix := r.normalizeHeader(fp.tagOpts.prefix)
+		for i, name := range r.header {
+			if strings.HasPrefix(r.normalizeHeader(name), prefix) {
+				columns = append(columns, i)
+			}
+		}
+	} else {
+		for i := fp.tagOpts.index; i <= fp.tagOpts.indexEnd; i++ {
+			columns = append(columns, i)
+		}
+	}
+	if t.Kind() == reflect.Array && len(columns) > t.Len() {
+		return fmt.Errorf("failed to read field %s [%s]: %d columns do not fit into an array of length %d", fp.name, fp.structField.Tag, len(columns), t.Len())
+	}
+	if len(columns) > 0 {
+		fp.column = columns[0]
+	}
+
+	fp.elems = make([]fieldPlan, len(columns))
+	for i, column := range columns {
+		elem := fieldPlan{taggedField: fp.taggedField, column: column, nullValues: fp.nullValues}
+		elem.name = fmt.Sprintf("%s[%d]", fp.name, i)
+		elem.structField.Type = t.Elem()
+		elem.tagOpts.prefix, elem.tagOpts.indexEnd = "", -1
+		if fp.tagOpts.prefix != "" {
+			elem.columnName = r.header[column]
+			elem.tagOpts.columnName, elem.tagOpts.columnNames = elem.columnName, []string{elem.columnName}
+		} else {
+			elem.tagOpts.index = column
+		}
+
+		if err := r.compileField(&elem, elem.structField.Type); err != nil {
+			return err
+		}
+		fp.elems[i] = elem
+	}
+	return nil
+}
+
+// resolveColumn returns the first of the given column names that is part of the header and its index.
+// If occurrence is set, the index of the nth column with that name is returned.
+func (r *CSVReader) resolveColumn(names []string, occurrence int) (string, int) {
+	for _, name := range names {
+		key := r.normalizeHeader(name)
+		if occurrence > 0 {
+			if positions := r.columnPositions[key]; occurrence <= len(positions) {
+				return name, positions[occurrence-1]
+			}
+			continue
+		}
+		if i, ok := r.columnIndex[key]; ok {
+			return name, i
+		}
+	}
+	return strings.Join(names, "|"), -1
+}
+
+// decode decodes the current line into rv and calls UnmarshalCSVRow and Validate if rv implements RowUnmarshaler or
+// Validator. It stops at the first error, or decodes all fields and joins their errors if the WithCollectErrors option
+// is set. Validate is only called if there were no errors.
+func (r *CSVReader) decode(p *structPlan, rv reflect.Value) error {
+	var errs []error
+	for i := range p.fields {
+		fv, err := fieldByIndex(rv, p.fields[i].index)
+		if err == nil {
+			err = r.decodeField(&p.fields[i], fv)
+		}
+		if err != nil {
+			if !r.collectErrors {
+				return err
+			}
+			errs = append(errs, err)
+		}
+	}
+
+	if u, ok := rv.Addr().Interface().(RowUnmarshaler); ok {
+		if err := u.UnmarshalCSVRow(Row{r: r}); err != nil {
+			if !r.collectErrors {
+				return r.lineError(err)
+			}
+			errs = append(errs, r.lineError(err))
+		}
+	}
+
+	if v, ok := rv.Addr().Interface().(Validator); ok && len(errs) == 0 {
+		if err := v.Validate(); err != nil {
+			return r.lineError(validationError(err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (r *CSVReader) decodeField(fp *fieldPlan, rv reflect.Value) error {
+	if fp.tagOpts.extra {
+		return r.decodeExtra(fp, rv)
+	}
+	if fp.tagOpts.multiColumn() {
+		return r.decodeElems(fp, rv)
+	}
+
+	value, err := r.fieldValue(fp)
+	if slices.Contains(fp.nullValues, value) {
+		value = ""
+	}
+	if value == "" && fp.tagOpts.defaultVal != nil {
+		value, err = *fp.tagOpts.defaultVal, nil
+	}
+	if err != nil {
+		return r.parseError(fp, value, err)
+	}
+
+	if fp.tagOpts.required {
+		if err := validateRequired(value); err != nil {
+			return r.parseError(fp, value, err)
+		}
+	}
+
+	if err := fp.convert(value, rv); err != nil {
+		return r.parseError(fp, value, fmt.Errorf("failed to convert value to type %s: %w", fp.structField.Type.Kind(), err))
+	}
+
+	// empty values are only checked by the required option
+	if value == "" {
+		return nil
+	}
+	for _, validate := range fp.validators {
+		if err := validate(value, rv); err != nil {
+			return r.parseError(fp, value, err)
+		}
+	}
+	return nil
+}
+
+// decodeElems decodes the columns of a slice or array field bound to a column range or to repeated columns in order.
+func (r *CSVReader) decodeElems(fp *fieldPlan, rv reflect.Value) error {
+	if len(fp.elems) == 0 && fp.tagOpts.defaultVal == nil {
+		return r.parseError(fp, "", ErrColumnNotFound)
+	}
+
+	rv.Set(reflect.Zero(rv.Type()))
+	if rv.Kind() == reflect.Slice {
+		rv.Set(reflect.MakeSlice(rv.Type(), len(fp.elems), len(fp.elems)))
+	}
+	for i := range fp.elems {
+		if err := r.decodeField(&fp.elems[i], rv.Index(i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decodeExtra fills a map field with the extra option with the values of the unbound columns by their header names.
+func (r *CSVReader) decodeExtra(fp *fieldPlan, rv reflect.Value) error {
+	m := reflect.MakeMapWithSize(rv.Type(), len(fp.extra))
+	for _, column := range fp.extra {
+		value, err := r.GetByColumnIndex(column)
+		if err != nil {
+			return r.parseError(fp, value, err)
+		}
+		key := reflect.ValueOf(r.header[column]).Convert(rv.Type().Key())
+		m.SetMapIndex(key, reflect.ValueOf(value).Convert(rv.Type().Elem()))
+	}
+	rv.Set(m)
+	return nil
+}
+
+// fieldValue returns the CSV value of the given field in the current line.
+func (r *CSVReader) fieldValue(fp *fieldPlan) (string, error) {
+	switch {
+	case fp.tagOpts.line:
+		return strconv.Itoa(r.CurrentLineIndex()), nil
+	case fp.tagOpts.record:
+		return strconv.Itoa(r.CurrentRecordIndex()), nil
+	case fp.tagOpts.raw:
+		return r.CurrentRawRecord(), nil
+	}
+
+	if fp.tagOpts.columnName != "" {
+		if fp.column < 0 {
+			return "", ErrColumnNotFound
+		}
+		return r.GetByColumnIndex(fp.column)
+	}
+
+	if fp.column >= len(r.columns) {
+		return "", ErrIndexOutOfRange
+	}
+	return r.columns[fp.column], nil
+}
+
+func (r *CSVReader) parseError(fp *fieldPlan, value string, err error) *ParseError {
+	return &ParseError{
+		Line:        r.lineOfColumn(fp.column),
+		Record:      r.CurrentRecordIndex(),
+		Column:      fp.columnName,
+		ColumnIndex: fp.column,
+		Field:       fp.name,
+		Value:       value,
+		Err:         err,
+	}
+}
+
+// fieldByIndex returns the nested field of rv by its index sequence. Nil pointers to nested structs are allocated.
+func fieldByIndex(rv reflect.Value, index []int) (reflect.Value, error) {
+	for i, x := range index {
+		if i > 0 && rv.Kind() == reflect.Ptr {
+			if rv.IsNil() {
+				if !rv.CanSet() {
+					return reflect.Value{}, fmt.Errorf("cannot set embedded pointer to unexported struct %s", rv.Type().Elem())
+				}
+				rv.Set(reflect.New(rv.Type().Elem()))
+			}
+			rv = rv.Elem()
+		}
+		rv = rv.Field(x)
+	}
+	return rv, nil
+}
+
+// firstOption returns the custom tag option with the smallest key, for a stable error message.
+func firstOption(options map[string]string) string {
+	key := slices.Min(slices.Collect(maps.Keys(options)))
+	return key + ":" + options[key]
+}
@@ -0,0 +1,297 @@
+package vcsv
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPlanIsRebuiltOnHeaderChange(t *testing.T) {
+	type data struct {
+		Name string `csv:"name"`
+		Age  int    `csv:"age"`
+	}
+
+	csvReader, err := New(strings.NewReader("name,age\nalice,30\nage,name\n31,bob"))
+	MustNoError(t, err)
+
+	var got []data
+	for csvReader.Next(&err) {
+		if csvReader.CurrentLineIndex() == 3 {
+			csvReader.ReadHeader()
+			continue
+		}
+
+		var d data
+		MustNoError(t, csvReader.UnmarshalLine(&d))
+		got = append(got, d)
+	}
+	MustNoError(t, err)
+
+	expect := []data{{Name: "alice", Age: 30}, {Name: "bob", Age: 31}}
+	if ok := reflect.DeepEqual(expect, got); !ok {
+		t.Fatalf("Expected %+v but got %+v", expect, got)
+	}
+}
+
+type benchmarkStruct struct {
+	ID        int       `csv:"id"`
+	Name      string    `csv:"name"`
+	Amount    float64   `csv:"amount"`
+	Paid      bool      `csv:"paid"`
+	CreatedAt time.Time `csv:"created_at,format:2006-01-02"`
+	Comment   string    `csv:"comment"`
+}
+
+const benchmarkLines = 1000
+
+func benchmarkData() string {
+	var sb strings.Builder
+	sb.WriteString("id,name,amount,paid,created_at,comment\n")
+	for i := 0; i < benchmarkLines; i++ {
+		sb.WriteString("42,alice,123.45,true,2023-12-04,some comment\n")
+	}
+	return sb.String()
+}
+
+// BenchmarkUnmarshalLine decodes every line with the cached plan.
+func BenchmarkUnmarshalLine(b *testing.B) {
+	data := benchmarkData()
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		csvReader, err := New(strings.NewReader(data))
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		var v benchmarkStruct
+		for csvReader.Next(&err) {
+			if err := csvReader.UnmarshalLine(&v); err != nil {
+				b.Fatal(err)
+			}
+		}
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkUnmarshalLineUncached decodes every line with a copy of the decoding path before plans were cached,
+// which parsed the tags, looked up the columns by name and converted the values by their kind for every line.
+func BenchmarkUnmarshalLineUncached(b *testing.B) {
+	data := benchmarkData()
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		csvReader, err := New(strings.NewReader(data))
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		var v benchmarkStruct
+		for csvReader.Next(&err) {
+			if err := legacyUnmarshalLine(csvReader, &v); err != nil {
+				b.Fatal(err)
+			}
+		}
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// legacyTagOptions, legacyReadTag, legacyUnmarshalLine and legacyConvertToType are copies of the decoding path
+// before plans were cached, reduced to the field types of benchmarkStruct. They are only used by
+// BenchmarkUnmarshalLineUncached.
+type legacyTagOptions struct {
+	columnName string
+	index      int
+	format     string
+}
+
+func legacyReadTag(tag reflect.StructTag) (*legacyTagOptions, error) {
+	tv := tag.Get("csv")
+	if tv == "" || tv == "-" {
+		return nil, nil
+	}
+
+	t := &legacyTagOptions{index: -1}
+	for _, opt := range strings.Split(tv, ",") {
+		opt = strings.TrimSpace(opt)
+		switch {
+		case strings.HasPrefix(opt, "index:"):
+			index, err := strconv.Atoi(opt[6:])
+			if err != nil {
+				return nil, err
+			}
+			t.index = index
+		case strings.HasPrefix(opt, "format:"):
+			t.format = opt[7:]
+		default:
+			t.columnName = opt
+		}
+	}
+	return t, nil
+}
+
+func legacyUnmarshalLine(r *CSVReader, v interface{}) error {
+	rv := reflect.ValueOf(v).Elem()
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		structField := rt.Field(i)
+		tagOpts, err := legacyReadTag(structField.Tag)
+		if err != nil {
+			return err
+		}
+		if tagOpts == nil {
+			continue
+		}
+
+		var value string
+		switch {
+		case tagOpts.columnName != "":
+			if value, err = r.Get(tagOpts.columnName); err != nil {
+				return err
+			}
+		case tagOpts.index >= 0:
+			if tagOpts.index >= len(r.columns) {
+				return fmt.Errorf("index %d out of range for field %s [%s]", tagOpts.index, structField.Name, structField.Tag)
+			}
+			value = r.columns[tagOpts.index]
+		default:
+			continue
+		}
+
+		converted, err := legacyConvertToType(value, structField.Type, *tagOpts)
+		if err != nil {
+			return fmt.Errorf("failed to convert value %s to type %s in field %s [%s]: %w",
+				value, structField.Type.Kind(), structField.Name, structField.Tag, err)
+		}
+		rv.Field(i).Set(converted)
+	}
+	return nil
+}
+
+func legacyConvertToType(value string, t reflect.Type, tagOpts legacyTagOptions) (reflect.Value, error) {
+	var err error
+	var result interface{}
+
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		result, err = strconv.ParseInt(value, 10, t.Bits())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		result, err = strconv.ParseUint(value, 10, t.Bits())
+	case reflect.Float32, reflect.Float64:
+		result, err = strconv.ParseFloat(value, t.Bits())
+	case reflect.Complex64, reflect.Complex128:
+		result, err = strconv.ParseComplex(value, t.Bits())
+	case reflect.Bool:
+		result, err = strconv.ParseBool(value)
+	case reflect.String:
+		result = value
+	case reflect.Struct:
+		if t != reflect.TypeOf(time.Time{}) {
+			return reflect.Value{}, fmt.Errorf("unsupported type %s", t.Kind())
+		}
+		result, err = time.Parse(tagOpts.format, value)
+	default:
+		return reflect.Value{}, fmt.Errorf("unsupported type %s", t.Kind())
+	}
+
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	return reflect.ValueOf(result).Convert(t), nil
+}
+
+type nestedAddress struct {
+	Street string `csv:"street"`
+	City   string `csv:"city"`
+}
+
+type nestedContact struct {
+	Email string `csv:"email"`
+}
+
+type NestedMeta struct {
+	Source  string        `csv:"source"`
+	Contact nestedContact `csv:"contact_,inline"`
+}
+
+type nestedOrder struct {
+	ID       int            `csv:"id"`
+	Billing  nestedAddress  `csv:"billing_,inline"`
+	Shipping *nestedAddress `csv:"shipping_,inline"`
+	NestedMeta
+	Ignored nestedAddress `csv:"-"`
+}
+
+func TestNestedStructs(t *testing.T) {
+	csvData := "id,billing_street,billing_city,shipping_street,shipping_city,source,contact_email\n" +
+		"1,Main St,Köln,Side St,Berlin,web,alice@example.com\n" +
+		"notanint,,,,,,"
+
+	csvReader, err := New(strings.NewReader(csvData))
+	MustNoError(t, err)
+
+	csvReader.Next(&err)
+	MustNoError(t, err)
+
+	var result nestedOrder
+	MustNoError(t, csvReader.UnmarshalLine(&result))
+
+	expect := nestedOrder{
+		ID:         1,
+		Billing:    nestedAddress{Street: "Main St", City: "Köln"},
+		Shipping:   &nestedAddress{Street: "Side St", City: "Berlin"},
+		NestedMeta: NestedMeta{Source: "web", Contact: nestedContact{Email: "alice@example.com"}},
+	}
+	if ok := reflect.DeepEqual(expect, result); !ok {
+		t.Fatalf("Expected %+v but got %+v", expect, result)
+	}
+
+	csvReader.Next(&err)
+	MustNoError(t, err)
+
+	var parseErr *ParseError
+	if err := csvReader.UnmarshalLine(&result); !errors.As(err, &parseErr) || parseErr.Field != "ID" {
+		t.Fatalf("Expected error for field ID but got %v", err)
+	}
+
+	csvReader, err = New(strings.NewReader("billing_street\nMain St"))
+	MustNoError(t, err)
+
+	csvReader.Next(&err)
+	MustNoError(t, err)
+
+	var billing struct {
+		Billing nestedAddress `csv:"billing_,inline"`
+	}
+	err = csvReader.UnmarshalLine(&billing)
+	if !errors.As(err, &parseErr) || parseErr.Field != "Billing.City" || parseErr.Column != "billing_city" {
+		t.Fatalf("Expected error for field Billing.City but got %v", err)
+	}
+}
+
+func TestRecursiveInlineStruct(t *testing.T) {
+	type node struct {
+		Name string `csv:"name"`
+		Next *node  `csv:"next_,inline"`
+	}
+
+	csvReader, err := New(strings.NewReader("name\nalice"))
+	MustNoError(t, err)
+
+	csvReader.Next(&err)
+	MustNoError(t, err)
+
+	var result node
+	MustError(t, csvReader.UnmarshalLine(&result))
+}
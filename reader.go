@@ -7,17 +7,36 @@ import (
 	"fmt"
 	"io"
 	"reflect"
-	"sort"
 )
 
 // CSVReader is a CSV reader that supports iterating and reading CSV lines into structs.
 // The csv reader is read by default in the first line. If the header is not in the first line,
 // you can use the WithReadHeader option to set the line where the header is located.
 type CSVReader struct {
-	columnIndex  map[string]int
-	columns      []string
-	reader       *csv.Reader
-	headerAtLine int
+	columnIndex      map[string]int
+	columnPositions  map[string][]int
+	duplicateHeaders DuplicateHeaders
+	header           []string
+	headerErr        error // error of the last header, returned by UnmarshalLine and Validate
+	headerNormalizer func(string) string
+	extraColumns     ExtraColumns
+	warningHandler   func(error)
+	columns          []string
+	reader           *csv.Reader
+	rawReader        *rawRecorder
+	separationChar   rune
+	encoding         Encoding
+	encodingFallback *Encoding
+	headerAtLine     int
+	headerSet        bool
+	autoDetect       bool
+	recordLen        int
+	records          int
+	collectErrors    bool
+	maxErrors        int
+	nullValues       []string
+	converters       map[reflect.Type]registeredConverter
+	plans            map[reflect.Type]*structPlan
 }
 
 // New creates a new CSVReader.
@@ -26,19 +45,40 @@ func New(r io.Reader, options ...Option) (*CSVReader, error) {
 		return nil, errors.New("reader must not be nil")
 	}
 
-	r, err := skipBOM(r)
+	c := CSVReader{}
+	for _, option := range options {
+		option(&c)
+	}
+
+	r, bomEncoding, err := skipBOM(r)
 	if err != nil {
 		return nil, fmt.Errorf("unable to skip BOM: %w", err)
 	}
+	if bomEncoding != nil {
+		c.encoding = *bomEncoding
+	} else if c.encodingFallback != nil && c.encoding == EncodingUTF8 {
+		r, c.encoding, err = detectEncoding(r, *c.encodingFallback)
+		if err != nil {
+			return nil, fmt.Errorf("unable to detect encoding: %w", err)
+		}
+	}
 
-	c := CSVReader{}
-	c.reader = csv.NewReader(r)
-	c.reader.FieldsPerRecord = -1
-	c.reader.LazyQuotes = true
+	r = newDecoder(r, c.encoding)
+	if c.autoDetect {
+		var dialect Dialect
+		if dialect, r, err = Sniff(r); err != nil {
+			return nil, fmt.Errorf("unable to detect dialect: %w", err)
+		}
+		c.applyDialect(dialect)
+	}
 
-	for _, option := range options {
-		option(&c)
+	c.rawReader = &rawRecorder{r: r}
+	c.reader = csv.NewReader(c.rawReader)
+	if c.separationChar != 0 {
+		c.reader.Comma = c.separationChar
 	}
+	c.reader.FieldsPerRecord = -1
+	c.reader.LazyQuotes = true
 
 	if err := c.readHeaderAtLine(c.headerAtLine); err != nil {
 		return nil, err
@@ -46,64 +86,47 @@ func New(r io.Reader, options ...Option) (*CSVReader, error) {
 	return &c, nil
 }
 
-func skipBOM(r io.Reader) (io.Reader, error) {
-	var (
-		utf8BOM    = []byte{0xEF, 0xBB, 0xBF}
-		utf16LEBOM = []byte{0xFF, 0xFE}
-		utf16BEBOM = []byte{0xFE, 0xFF}
-		utf32LEBOM = []byte{0xFF, 0xFE, 0x00, 0x00}
-		utf32BEBOM = []byte{0x00, 0x00, 0xFE, 0xFF}
-	)
-
-	buf := make([]byte, 4)
-
-	n, err := io.ReadFull(r, buf)
-	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
-		return nil, err
+// applyDialect applies the detected dialect, unless the separation character or the header was set by an option.
+func (r *CSVReader) applyDialect(dialect Dialect) {
+	if r.separationChar == 0 {
+		r.separationChar = dialect.Separator
 	}
-
-	switch {
-	case bytes.HasPrefix(buf[:n], utf32LEBOM), bytes.HasPrefix(buf[:n], utf32BEBOM):
-		return io.MultiReader(bytes.NewReader(buf[len(utf32LEBOM):n]), r), nil
-	case bytes.HasPrefix(buf[:n], utf16LEBOM), bytes.HasPrefix(buf[:n], utf16BEBOM):
-		return io.MultiReader(bytes.NewReader(buf[len(utf16LEBOM):n]), r), nil
-	case bytes.HasPrefix(buf[:n], utf8BOM):
-		return io.MultiReader(bytes.NewReader(buf[len(utf8BOM):n]), r), nil
+	if !r.headerSet && !dialect.HasHeader {
+		r.headerAtLine = -1
 	}
-
-	return io.MultiReader(bytes.NewReader(buf[:n]), r), nil
 }
 
 // Header returns the CSV header columns.
 func (r *CSVReader) Header() []string {
-	keys := make([]string, 0, len(r.columnIndex))
-	for k := range r.columnIndex {
-		keys = append(keys, k)
-	}
-	sort.SliceStable(keys, func(i, j int) bool {
-		return r.columnIndex[keys[i]] < r.columnIndex[keys[j]]
-	})
-	return keys
+	return append([]string(nil), r.header...)
 }
 
-// SetHeader sets the CSV header columns.
+// SetHeader sets the CSV header columns. The column names are normalized by the WithHeaderNormalizer option.
+// Duplicate column names are handled by the WithDuplicateHeaders option. If duplicate column names are an error,
+// the error is returned by UnmarshalLine and Validate.
 func (r *CSVReader) SetHeader(columns []string) {
-	r.columns = columns
-	r.columnIndex = make(map[string]int)
-	for i, name := range r.columns {
-		r.columnIndex[name] = i
+	header, err := r.indexHeader(columns)
+	r.headerErr = err
+	if err != nil {
+		return
 	}
+
+	r.columns = columns
+	r.header = header
+	r.plans = nil
 }
 
 // ReadHeader reads the current line, that was already read by Next as the CSV header.
 // This method is called automatically when the CSVReader is created, use it only if you want to read the header again.
 func (r *CSVReader) ReadHeader() {
 	r.SetHeader(r.columns)
+	r.records = 0
 }
 
 // Next reads the next CSV line.
 func (r *CSVReader) Next(err *error) bool {
 	r.columns, *err = r.reader.Read()
+	r.rawReader.setRecord(r.reader.InputOffset())
 	if *err == io.EOF {
 		*err = nil
 		return false
@@ -111,12 +134,15 @@ func (r *CSVReader) Next(err *error) bool {
 	if *err != nil {
 		return false
 	}
+	r.recordLen = len(r.columns)
+	r.records++
 	return true
 }
 
 // Get returns the value of the given column name.
+// The column name is normalized by the WithHeaderNormalizer option.
 func (r *CSVReader) Get(columnName string) (string, error) {
-	i, columnExists := r.columnIndex[columnName]
+	i, columnExists := r.columnIndex[r.normalizeHeader(columnName)]
 	if !columnExists {
 		return "", fmt.Errorf("invalid column \"%s\"", columnName)
 	}
@@ -136,19 +162,76 @@ func (r *CSVReader) GetByColumnIndex(columnIndex int) (string, error) {
 
 // CurrentLineIndex returns the current CSV line index.
 func (r *CSVReader) CurrentLineIndex() int {
-	lineIndex, _ := r.reader.FieldPos(0)
-	return lineIndex
+	return r.lineOfColumn(0)
+}
+
+// CurrentRecordIndex returns the index of the current data record, starting at 0.
+// Lines before and including the header are not counted.
+func (r *CSVReader) CurrentRecordIndex() int {
+	return r.records - 1
+}
+
+// CurrentRawRecord returns the text of the current CSV line as it was read, without the line terminator.
+// The text of a record with quoted values spanning several lines contains these lines.
+func (r *CSVReader) CurrentRawRecord() string {
+	return r.rawReader.record()
+}
+
+// lineOfColumn returns the line of the given column in the current record. A quoted value may span
+// several lines, so the line of a column can be after the line where the record starts.
+func (r *CSVReader) lineOfColumn(columnIndex int) int {
+	if r.recordLen == 0 {
+		return 0
+	}
+	if columnIndex < 0 || columnIndex >= r.recordLen {
+		columnIndex = 0
+	}
+	line, _ := r.reader.FieldPos(columnIndex)
+	return line
 }
 
 // UnmarshalLine fills the given struct with data from the next CSV line.
 // The struct fields should be annotated with the `csv` tag to map to CSV column names.
-// The struct fields types may be any primitive type or implement encoding.TextUnmarshaler.
+// The struct fields types may be any primitive type or implement ValueUnmarshaler or encoding.TextUnmarshaler.
+// Pointers to these types and the nullable types of database/sql, like sql.NullString or sql.Null[T],
+// are supported as well. Empty values are read as nil pointers or invalid sql.Null* values.
+// Converters of the WithConverter option and of RegisterConverter take precedence over the built-in conversions.
 //
+// If the struct implements RowUnmarshaler, UnmarshalCSVRow is called after the tagged fields were read.
+// If the struct implements Validator, Validate is called after all fields were read without errors.
+// Errors of both methods are returned as ParseError with the line and record of the CSV line.
 //
 // Supported tag options:
 // - `csv:"<column_name>"` - maps the struct field to the given CSV column name.
+// - `csv:"<column_name>|<alternative>"` - maps the struct field to the first of the given CSV column names in the header.
+// - `csv:"<prefix>,inline"` - reads the fields of a nested struct, with the prefix added to their column names.
+//   Embedded structs without a tag are read like inline structs without a prefix.
+// - `csv:"<column_name>,occurrence:<n>"` - maps the struct field to the nth column with the given name, starting at 1.
 // - `csv:"index:<column_index>"` - maps the struct field to the given CSV column index.
+// - `csv:"index:<first>-<last>"` - reads a slice or array field from the given range of CSV column indexes.
+// - `csv:"prefix:<prefix>"` - reads a slice or array field from all CSV columns starting with the given prefix.
+//   The elements of column ranges and prefixes are read like single fields with the other tag options.
+// - `csv:",extra"` - fills a map[string]string or map[string]any field with all header columns that are not bound
+//   to another field, keyed by their column name.
+// - `csv:",line"`, `csv:",record"`, `csv:",raw"` - fills the struct field with the CurrentLineIndex, the
+//   CurrentRecordIndex or the CurrentRawRecord instead of a CSV column value.
+// - `csv:"<column_name>,<key>:<value>"` - passes custom options to the converters of WithConverter and RegisterConverter.
 // - `csv:"format:<time_format>"` - parses the CSV column value as a time.Time using the given format.
+//   time.Time fields without the format option are read and written as time.RFC3339.
+// - `csv:"<column_name>,split:<separator>"` - reads a slice or array field from a CSV column value, whose elements are
+//   separated by the given separator. Empty values are read as nil slices.
+// - `csv:"<column_name>,default:<value>"` - uses the given value if the CSV column value is empty or missing.
+// - `csv:"<column_name>,null:<a>|<b>"` - treats the given values as empty, instead of the values of the WithNullValues option.
+//
+// Supported validation tag options, checked after the value was converted:
+// - `csv:"<column_name>,required"` - the CSV column value must not be empty.
+// - `csv:"<column_name>,min:<number>,max:<number>"` - the numeric value must be within the given limits.
+// - `csv:"<column_name>,minlen:<length>,maxlen:<length>"` - the number of characters must be within the given limits.
+// - `csv:"<column_name>,oneof:<a>|<b>|<c>"` - the CSV column value must be one of the given values.
+// - `csv:"<column_name>,pattern:<regex>"` - the CSV column value must match the given regular expression.
+//   The pattern option must be the last option, so the regular expression may contain commas.
+//
+// Empty values are only checked by the `required` option. Validation errors wrap ErrValidation.
 //
 // Example:
 //
@@ -179,12 +262,19 @@ func (r *CSVReader) UnmarshalLine(v interface{}) error {
 		return errors.New("v must be a pointer to a struct")
 	}
 
-	rt := rv.Type()
-	return r.parseFields(rt, rv)
+	p, err := r.plan(rv.Type())
+	if err != nil {
+		return err
+	}
+	return r.decode(p, rv)
 }
 
 func (r *CSVReader) readHeaderAtLine(line int) (err error) {
 	if r.headerAtLine < 0 {
+		if r.header != nil {
+			r.SetHeader(r.header)
+			return r.headerErr
+		}
 		return nil
 	}
 
@@ -193,7 +283,7 @@ func (r *CSVReader) readHeaderAtLine(line int) (err error) {
 	}
 
 	r.ReadHeader()
-	return nil
+	return r.headerErr
 }
 
 func (r *CSVReader) skipLines(n int) error {
@@ -206,11 +296,39 @@ func (r *CSVReader) skipLines(n int) error {
 	return nil
 }
 
-func (r *CSVReader) parseFields(rt reflect.Type, rv reflect.Value) error {
-	for i := 0; i < rt.NumField(); i++ {
-		if err := r.parseFieldValue(rt.Field(i), rv.Field(i)); err != nil {
-			return err
-		}
+// rawRecorder keeps the bytes read by the csv.Reader since the start of the current record, so its raw text can be
+// returned. The bytes of previous records are only discarded when the csv.Reader reads more data, and the text is
+// only copied to a string if it is requested, so reading records without raw text stays cheap.
+type rawRecorder struct {
+	r      io.Reader
+	buf    []byte
+	offset int64 // input offset of the first byte of buf
+	start  int64 // input offset of the end of the previous record
+	end    int64 // input offset of the end of the current record
+}
+
+func (rr *rawRecorder) Read(p []byte) (int, error) {
+	if discard := int(rr.end - rr.offset); discard > 0 {
+		rr.buf = append(rr.buf[:0], rr.buf[discard:]...)
+		rr.offset = rr.end
 	}
-	return nil
+
+	n, err := rr.r.Read(p)
+	rr.buf = append(rr.buf, p[:n]...)
+	return n, err
+}
+
+// setRecord sets the input offset of the end of the current record, the record starts at the end of the previous one.
+func (rr *rawRecorder) setRecord(end int64) {
+	rr.start, rr.end = rr.end, end
+}
+
+// record returns the raw text of the current record. The empty lines skipped by the csv.Reader before the record
+// and the line terminator are removed.
+func (rr *rawRecorder) record() string {
+	raw := rr.buf[rr.start-rr.offset : rr.end-rr.offset]
+	raw = bytes.TrimLeft(raw, "\r\n")
+	raw = bytes.TrimSuffix(raw, []byte("\n"))
+	raw = bytes.TrimSuffix(raw, []byte("\r"))
+	return string(raw)
 }
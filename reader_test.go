@@ -2,9 +2,13 @@ package vcsv
 
 import (
 	"bytes"
+	"database/sql"
+	"errors"
+	"fmt"
 	"math/big"
 	"reflect"
 	"slices"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
@@ -229,18 +233,21 @@ func TestReadIntoStructByColumnIndex(t *testing.T) {
 }
 
 func Test_skipBOM(t *testing.T) {
+	encoding := func(e Encoding) *Encoding { return &e }
+
 	tests := []struct {
 		name     string
 		input    string
 		expected string
+		encoding *Encoding
 	}{
-		{"No BOM", "Hello, World!", "Hello, World!"},
-		{"UTF-8 BOM", "\xEF\xBB\xBFHello, World!", "Hello, World!"},
-		{"UTF-16LE BOM", "\xFF\xFEHello, World!", "Hello, World!"},
-		{"UTF-16BE BOM", "\xFE\xFFHello, World!", "Hello, World!"},
-		{"UTF-32LE BOM", "\xFF\xFE\x00\x00Hello, World!", "Hello, World!"},
-		{"UTF-32BE BOM", "\x00\x00\xFE\xFFHello, World!", "Hello, World!"},
-		{"Empty String", "", ""},
+		{"No BOM", "Hello, World!", "Hello, World!", nil},
+		{"UTF-8 BOM", "\xEF\xBB\xBFHello, World!", "Hello, World!", encoding(EncodingUTF8)},
+		{"UTF-16LE BOM", "\xFF\xFEHello, World!", "Hello, World!", encoding(EncodingUTF16LE)},
+		{"UTF-16BE BOM", "\xFE\xFFHello, World!", "Hello, World!", encoding(EncodingUTF16BE)},
+		{"UTF-32LE BOM", "\xFF\xFE\x00\x00Hello, World!", "Hello, World!", encoding(EncodingUTF32LE)},
+		{"UTF-32BE BOM", "\x00\x00\xFE\xFFHello, World!", "Hello, World!", encoding(EncodingUTF32BE)},
+		{"Empty String", "", "", nil},
 	}
 
 	for _, tc := range tests {
@@ -249,7 +256,7 @@ func Test_skipBOM(t *testing.T) {
 			r := strings.NewReader(tc.input)
 
 			// Execute the skipBOM function and capture the new reader
-			newReader, err := skipBOM(r)
+			newReader, enc, err := skipBOM(r)
 			if err != nil {
 				t.Fatalf("skipBOM() returned an error: %v", err)
 			}
@@ -265,10 +272,520 @@ func Test_skipBOM(t *testing.T) {
 			if got := buf.String(); got != tc.expected {
 				t.Errorf("Expected remaining data to be %q, got %q", tc.expected, got)
 			}
+
+			// Check if the detected encoding is as expected
+			if !reflect.DeepEqual(tc.encoding, enc) {
+				t.Errorf("Expected encoding %v, got %v", tc.encoding, enc)
+			}
+		})
+	}
+}
+
+func TestDefaultValues(t *testing.T) {
+	type data struct {
+		Name      string    `csv:"name,default:unknown"`
+		Age       int       `csv:"age,default:18"`
+		Active    bool      `csv:"active,default:true"`
+		Score     float64   `csv:"score,default:1.5"`
+		CreatedAt time.Time `csv:"created_at,format:2006-01-02,default:2023-12-04"`
+		Level     int       `csv:"index:5,default:3"`
+		Missing   string    `csv:"missing,default:none"`
+	}
+
+	defaults := data{Name: "unknown", Age: 18, Active: true, Score: 1.5, CreatedAt: time.Date(2023, 12, 4, 0, 0, 0, 0, time.UTC), Level: 3, Missing: "none"}
+
+	testCases := []struct {
+		name    string
+		csvData string
+		expect  data
+	}{
+		{
+			name:    "Empty Values",
+			csvData: "name,age,active,score,created_at,level\n,,,,,",
+			expect:  defaults,
+		},
+		{
+			name:    "Ragged Row",
+			csvData: "name,age,active,score,created_at,level\nalice",
+			expect:  data{Name: "alice", Age: 18, Active: true, Score: 1.5, CreatedAt: defaults.CreatedAt, Level: 3, Missing: "none"},
+		},
+		{
+			name:    "Values Set",
+			csvData: "name,age,active,score,created_at,level\nalice,30,false,2.5,2024-01-02,7",
+			expect:  data{Name: "alice", Age: 30, Active: false, Score: 2.5, CreatedAt: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), Level: 7, Missing: "none"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			csvReader, err := New(bytes.NewBufferString(tc.csvData))
+			MustNoError(t, err)
+
+			csvReader.Next(&err)
+			MustNoError(t, err)
+
+			var result data
+			MustNoError(t, csvReader.UnmarshalLine(&result))
+			if ok := reflect.DeepEqual(tc.expect, result); !ok {
+				t.Fatalf("Expected %+v but got %+v", tc.expect, result)
+			}
+		})
+	}
+}
+
+func TestInvalidDefaultValue(t *testing.T) {
+	type data struct {
+		Age int `csv:"age,default:notanint"`
+	}
+
+	csvReader, err := New(bytes.NewBufferString("age\n42"))
+	MustNoError(t, err)
+
+	csvReader.Next(&err)
+	MustNoError(t, err)
+
+	var result data
+	MustError(t, csvReader.UnmarshalLine(&result))
+}
+
+type NullableStruct struct {
+	IntPtr      *int            `csv:"int"`
+	FloatPtr    *float64        `csv:"float"`
+	BoolPtr     *bool           `csv:"bool"`
+	StringPtr   *string         `csv:"string"`
+	TimePtr     *time.Time      `csv:"time,format:2006-01-02"`
+	NullString  sql.NullString  `csv:"null_string"`
+	NullInt64   sql.NullInt64   `csv:"null_int"`
+	NullFloat64 sql.NullFloat64 `csv:"null_float"`
+	NullBool    sql.NullBool    `csv:"null_bool"`
+	NullTime    sql.NullTime    `csv:"null_time,format:2006-01-02"`
+	NullGeneric sql.Null[int]   `csv:"null_generic"`
+}
+
+func nullableCSV(vals string) string {
+	return "int,float,bool,string,time,null_string,null_int,null_float,null_bool,null_time,null_generic\n" + vals
+}
+
+func TestNullableFields(t *testing.T) {
+	ptr := func(v any) any {
+		rv := reflect.New(reflect.TypeOf(v))
+		rv.Elem().Set(reflect.ValueOf(v))
+		return rv.Interface()
+	}
+	date := time.Date(2023, 12, 4, 0, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name      string
+		csvData   string
+		expect    NullableStruct
+		expectErr bool
+	}{
+		{
+			name:    "Empty Values",
+			csvData: nullableCSV(",,,,,,,,,,"),
+			expect:  NullableStruct{},
+		},
+		{
+			name:    "Valid Data",
+			csvData: nullableCSV("42,1.5,true,hello,2023-12-04,hello,42,1.5,true,2023-12-04,7"),
+			expect: NullableStruct{
+				IntPtr:      ptr(42).(*int),
+				FloatPtr:    ptr(1.5).(*float64),
+				BoolPtr:     ptr(true).(*bool),
+				StringPtr:   ptr("hello").(*string),
+				TimePtr:     &date,
+				NullString:  sql.NullString{String: "hello", Valid: true},
+				NullInt64:   sql.NullInt64{Int64: 42, Valid: true},
+				NullFloat64: sql.NullFloat64{Float64: 1.5, Valid: true},
+				NullBool:    sql.NullBool{Bool: true, Valid: true},
+				NullTime:    sql.NullTime{Time: date, Valid: true},
+				NullGeneric: sql.Null[int]{V: 7, Valid: true},
+			},
+		},
+		{
+			name:      "Invalid Pointer Value",
+			csvData:   nullableCSV("notanint,,,,,,,,,,"),
+			expectErr: true,
+		},
+		{
+			name:      "Invalid Null Value",
+			csvData:   nullableCSV(",,,,,,notanint,,,,"),
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			csvReader, err := New(bytes.NewBufferString(tc.csvData))
+			MustNoError(t, err)
+
+			csvReader.Next(&err)
+			MustNoError(t, err)
+
+			var result NullableStruct
+			err = csvReader.UnmarshalLine(&result)
+
+			if tc.expectErr {
+				MustError(t, err)
+			} else {
+				MustNoError(t, err)
+				if ok := reflect.DeepEqual(tc.expect, result); !ok {
+					t.Fatalf("Expected %+v but got %+v", tc.expect, result)
+				}
+			}
+		})
+	}
+}
+
+func TestNullValues(t *testing.T) {
+	type data struct {
+		IntPtr     *int           `csv:"int"`
+		NullString sql.NullString `csv:"null_string"`
+		String     string         `csv:"string"`
+		Time       *time.Time     `csv:"time,format:2006-01-02"`
+		Default    int            `csv:"default,default:7"`
+		Sign       string         `csv:"sign,null:NULL"`
+		Dash       *int           `csv:"dash,null:-"`
+	}
+
+	csvData := "int,null_string,string,time,default,sign,dash\n" +
+		"NULL,\\N,N/A,NULL,NULL,-,-\n" +
+		"42,hello,world,2023-12-04,3,NULL,N/A"
+	csvReader, err := New(bytes.NewBufferString(csvData), WithNullValues("NULL", `\N`, "N/A"))
+	MustNoError(t, err)
+
+	csvReader.Next(&err)
+	MustNoError(t, err)
+
+	var result data
+	MustNoError(t, csvReader.UnmarshalLine(&result))
+	expect := data{Default: 7, Sign: "-"}
+	if ok := reflect.DeepEqual(expect, result); !ok {
+		t.Fatalf("Expected %+v but got %+v", expect, result)
+	}
+
+	csvReader.Next(&err)
+	MustNoError(t, err)
+
+	result = data{}
+	MustError(t, csvReader.UnmarshalLine(&result))
+
+	// a null value is an error for fields that cannot hold it
+	csvReader, err = New(bytes.NewBufferString("time\nNULL"), WithNullValues("NULL"))
+	MustNoError(t, err)
+
+	csvReader.Next(&err)
+	MustNoError(t, err)
+
+	var timeResult struct {
+		Time time.Time `csv:"time"`
+	}
+	MustError(t, csvReader.UnmarshalLine(&timeResult))
+}
+
+type sliceStruct struct {
+	Tags    []string    `csv:"tags,split:|"`
+	Numbers []int       `csv:"numbers,split:;"`
+	Scores  [3]float64  `csv:"scores,split:;"`
+	Dates   []time.Time `csv:"dates,split:/,format:2006-01-02"`
+	Ptrs    []*int      `csv:"ptrs,split:;"`
+}
+
+func TestSliceFields(t *testing.T) {
+	one, three := 1, 3
+
+	testCases := []struct {
+		name      string
+		row       string
+		expect    sliceStruct
+		expectErr bool
+	}{
+		{
+			name: "Valid Data",
+			row:  "a|b|c,1; 2;3,1.5;2,2023-12-04/2024-01-02,1;;3",
+			expect: sliceStruct{
+				Tags:    []string{"a", "b", "c"},
+				Numbers: []int{1, 2, 3},
+				Scores:  [3]float64{1.5, 2},
+				Dates:   []time.Time{time.Date(2023, 12, 4, 0, 0, 0, 0, time.UTC), time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)},
+				Ptrs:    []*int{&one, nil, &three},
+			},
+		},
+		{
+			name:   "Empty Values",
+			row:    ",,,,",
+			expect: sliceStruct{},
+		},
+		{
+			name:      "Invalid Element",
+			row:       ",1;x,,,",
+			expectErr: true,
+		},
+		{
+			name:      "Too Many Array Elements",
+			row:       ",,1;2;3;4,,",
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			csvReader, err := New(bytes.NewBufferString("tags,numbers,scores,dates,ptrs\n" + tc.row))
+			MustNoError(t, err)
+
+			csvReader.Next(&err)
+			MustNoError(t, err)
+
+			var result sliceStruct
+			err = csvReader.UnmarshalLine(&result)
+
+			if tc.expectErr {
+				MustError(t, err)
+			} else {
+				MustNoError(t, err)
+				if ok := reflect.DeepEqual(tc.expect, result); !ok {
+					t.Fatalf("Expected %+v but got %+v", tc.expect, result)
+				}
+			}
+		})
+	}
+}
+
+func TestSliceFieldWithoutSplit(t *testing.T) {
+	type data struct {
+		Tags []string `csv:"tags"`
+	}
+
+	csvReader, err := New(bytes.NewBufferString("tags\na"))
+	MustNoError(t, err)
+
+	csvReader.Next(&err)
+	MustNoError(t, err)
+
+	var result data
+	MustError(t, csvReader.UnmarshalLine(&result))
+}
+
+func TestMultiColumnFields(t *testing.T) {
+	type data struct {
+		Name   string    `csv:"index:0"`
+		Sales  []int     `csv:"index:1-3,default:0"`
+		Scores [2]*int   `csv:"index:4-5"`
+		Weeks  []float64 `csv:"prefix:week_,min:0"`
+	}
+
+	one, two := 1, 2
+
+	testCases := []struct {
+		name      string
+		header    string
+		row       string
+		expect    data
+		expectErr bool
+	}{
+		{
+			name:   "Valid Data",
+			header: "name,a,b,c,d,e,week_1,week_2,total,week_3",
+			row:    "alice,1,,3,1,2,1.5,0,9,2.5",
+			expect: data{Name: "alice", Sales: []int{1, 0, 3}, Scores: [2]*int{&one, &two}, Weeks: []float64{1.5, 0, 2.5}},
+		},
+		{
+			name:   "Empty Elements",
+			header: "name,a,b,c,d,e,week_1",
+			row:    "bob,1,,,,,0",
+			expect: data{Name: "bob", Sales: []int{1, 0, 0}, Weeks: []float64{0}},
+		},
+		{
+			name:      "Invalid Element",
+			header:    "name,a,b,c,d,e,week_1",
+			row:       "alice,1,x,3,,,",
+			expectErr: true,
+		},
+		{
+			name:      "Invalid Prefix Element",
+			header:    "name,a,b,c,d,e,week_1",
+			row:       "alice,1,2,3,,,-1",
+			expectErr: true,
+		},
+		{
+			name:      "Missing Prefix Columns",
+			header:    "name,a,b,c,d,e",
+			row:       "alice,1,2,3,,",
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			csvReader, err := New(bytes.NewBufferString(tc.header + "\n" + tc.row))
+			MustNoError(t, err)
+
+			csvReader.Next(&err)
+			MustNoError(t, err)
+
+			var result data
+			err = csvReader.UnmarshalLine(&result)
+
+			if tc.expectErr {
+				MustError(t, err)
+			} else {
+				MustNoError(t, err)
+				if ok := reflect.DeepEqual(tc.expect, result); !ok {
+					t.Fatalf("Expected %+v but got %+v", tc.expect, result)
+				}
+			}
+		})
+	}
+}
+
+func TestMultiColumnFieldErrors(t *testing.T) {
+	testCases := []struct {
+		name string
+		v    any
+	}{
+		{name: "Not A Slice", v: &struct {
+			Sales int `csv:"index:1-3"`
+		}{}},
+		{name: "Array Too Short", v: &struct {
+			Sales [2]int `csv:"index:1-3"`
+		}{}},
+		{name: "Invalid Range", v: &struct {
+			Sales []int `csv:"index:3-1"`
+		}{}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			csvReader, err := New(bytes.NewBufferString("a,b,c,d\n1,2,3,4"))
+			MustNoError(t, err)
+
+			csvReader.Next(&err)
+			MustNoError(t, err)
+
+			MustError(t, csvReader.UnmarshalLine(tc.v))
 		})
 	}
 }
 
+func TestExtraField(t *testing.T) {
+	type data struct {
+		Name  string            `csv:"name"`
+		Weeks []int             `csv:"prefix:week_"`
+		Extra map[string]string `csv:",extra"`
+	}
+	type anyData struct {
+		Name  string         `csv:"index:0"`
+		Extra map[string]any `csv:",extra"`
+	}
+
+	csvData := "name,zip,week_1,comment\nalice,50667,1"
+	csvReader, err := New(bytes.NewBufferString(csvData))
+	MustNoError(t, err)
+
+	csvReader.Next(&err)
+	MustNoError(t, err)
+
+	var result data
+	MustNoError(t, csvReader.UnmarshalLine(&result))
+	expect := data{Name: "alice", Weeks: []int{1}, Extra: map[string]string{"zip": "50667", "comment": ""}}
+	if ok := reflect.DeepEqual(expect, result); !ok {
+		t.Fatalf("Expected %+v but got %+v", expect, result)
+	}
+
+	var anyResult anyData
+	MustNoError(t, csvReader.UnmarshalLine(&anyResult))
+	anyExpect := anyData{Name: "alice", Extra: map[string]any{"zip": "50667", "week_1": "1", "comment": ""}}
+	if ok := reflect.DeepEqual(anyExpect, anyResult); !ok {
+		t.Fatalf("Expected %+v but got %+v", anyExpect, anyResult)
+	}
+
+	var invalid struct {
+		Extra map[string]int `csv:",extra"`
+	}
+	MustError(t, csvReader.UnmarshalLine(&invalid))
+}
+
+func TestMetadataFields(t *testing.T) {
+	type data struct {
+		Name   string `csv:"name"`
+		Line   int    `csv:",line"`
+		Record int64  `csv:",record"`
+		Raw    string `csv:",raw"`
+	}
+
+	csvData := "# export\r\nname,comment\r\nalice,\"multi\r\nline\"\r\nbob,plain"
+	csvReader, err := New(bytes.NewBufferString(csvData), WithReadHeader(1))
+	MustNoError(t, err)
+
+	var got []data
+	for csvReader.Next(&err) {
+		var line data
+		MustNoError(t, csvReader.UnmarshalLine(&line))
+		got = append(got, line)
+	}
+	MustNoError(t, err)
+
+	expect := []data{
+		{Name: "alice", Line: 3, Record: 0, Raw: "alice,\"multi\r\nline\""},
+		{Name: "bob", Line: 5, Record: 1, Raw: "bob,plain"},
+	}
+	if ok := reflect.DeepEqual(expect, got); !ok {
+		t.Fatalf("Expected %+v but got %+v", expect, got)
+	}
+	MustNoError(t, csvReader.Validate(reflect.TypeOf(data{})))
+}
+
+func TestCurrentRawRecordSkipsEmptyLines(t *testing.T) {
+	csvReader, err := New(bytes.NewBufferString("a,b\n\n\nq,1\r\n\r\nr,2\n"))
+	MustNoError(t, err)
+
+	var got []string
+	for csvReader.Next(&err) {
+		got = append(got, csvReader.CurrentRawRecord())
+	}
+	MustNoError(t, err)
+
+	if expect := []string{"q,1", "r,2"}; !slices.Equal(expect, got) {
+		t.Fatalf("Expected %q but got %q", expect, got)
+	}
+}
+
+type price int64
+
+// UnmarshalCSV reads prices in cents with the decimal separator of the `decimal` tag option.
+func (p *price) UnmarshalCSV(value string, info FieldInfo) error {
+	if info.Column != "price" || info.Format != "cents" {
+		return fmt.Errorf("unexpected field info %+v", info)
+	}
+	cents, err := strconv.ParseInt(strings.Replace(value, info.Options["decimal"], "", 1), 10, 64)
+	*p = price(cents)
+	return err
+}
+
+// UnmarshalText is not used, because UnmarshalCSV takes precedence.
+func (p *price) UnmarshalText([]byte) error {
+	return errors.New("unexpected UnmarshalText call")
+}
+
+func TestValueUnmarshaler(t *testing.T) {
+	type data struct {
+		Price    price  `csv:"price,format:cents,decimal:."`
+		PricePtr *price `csv:"price,format:cents,decimal:."`
+	}
+
+	csvReader, err := New(bytes.NewBufferString("price\n12.50"))
+	MustNoError(t, err)
+
+	csvReader.Next(&err)
+	MustNoError(t, err)
+
+	var result data
+	MustNoError(t, csvReader.UnmarshalLine(&result))
+	if result.Price != 1250 || result.PricePtr == nil || *result.PricePtr != 1250 {
+		t.Fatalf("Expected price 1250 but got %+v", result)
+	}
+}
+
 func MustNoError(t *testing.T, err error) {
 	if err != nil {
 		t.Fatal(err)
@@ -0,0 +1,95 @@
+package vcsv
+
+import (
+	"reflect"
+	"sync"
+)
+
+// FieldInfo describes the struct field and the CSV column a value is converted for.
+type FieldInfo struct {
+	Name    string            // The name of the struct field, with the path of nested structs, e.g. "Billing.Street".
+	Column  string            // The name of the CSV column, empty if the field is mapped by index.
+	Index   int               // The index of the CSV column, -1 if the column is not part of the header.
+	Format  string            // The value of the `format` tag option.
+	Options map[string]string // Other `<key>:<value>` tag options, e.g. `csv:"weight,unit:kg"`.
+}
+
+// registeredConverter converts a value with a converter of WithConverter or RegisterConverter.
+type registeredConverter func(value string, info FieldInfo) (reflect.Value, error)
+
+// defaultConverters is the package-level registry of RegisterConverter.
+var defaultConverters = struct {
+	sync.RWMutex
+	m map[reflect.Type]registeredConverter
+}{m: make(map[reflect.Type]registeredConverter)}
+
+// RegisterConverter registers a converter for the type T, that is used by all CSVReaders without a converter for T
+// of the WithConverter option. The converter takes precedence over the built-in conversions of T, including time.Time
+// and encoding.TextUnmarshaler. It only applies to struct types read for the first time after registration,
+// so it should be called during initialization.
+func RegisterConverter[T any](convert func(value string, info FieldInfo) (T, error)) {
+	defaultConverters.Lock()
+	defer defaultConverters.Unlock()
+	defaultConverters.m[typeOf[T]()] = newRegisteredConverter(convert)
+}
+
+func newRegisteredConverter[T any](convert func(value string, info FieldInfo) (T, error)) registeredConverter {
+	return func(value string, info FieldInfo) (reflect.Value, error) {
+		result, err := convert(value, info)
+		return reflect.ValueOf(&result).Elem(), err
+	}
+}
+
+func typeOf[T any]() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
+
+// customConverters looks up the converters of the WithConverter option and of RegisterConverter for a single field.
+type customConverters struct {
+	converters map[reflect.Type]registeredConverter
+	info       FieldInfo
+}
+
+func (c customConverters) lookup(t reflect.Type) (converterFunc, bool) {
+	convert, ok := c.converters[t]
+	if !ok {
+		defaultConverters.RLock()
+		convert, ok = defaultConverters.m[t]
+		defaultConverters.RUnlock()
+	}
+	if !ok {
+		return nil, false
+	}
+
+	info := c.info
+	return func(value string, rv reflect.Value) error {
+		result, err := convert(value, info)
+		if err != nil {
+			return err
+		}
+		rv.Set(result)
+		return nil
+	}, true
+}
+
+// readsOptions reports whether the converter of t gets the custom tag options, because t, its element type or the
+// value type of a nullable type of database/sql has a custom converter or implements ValueUnmarshaler.
+func (c customConverters) readsOptions(t reflect.Type) bool {
+	if _, ok := c.converters[t]; ok {
+		return true
+	}
+	defaultConverters.RLock()
+	_, ok := defaultConverters.m[t]
+	defaultConverters.RUnlock()
+	if ok || reflect.PointerTo(t).Implements(reflect.TypeOf((*ValueUnmarshaler)(nil)).Elem()) {
+		return true
+	}
+
+	switch {
+	case t.Kind() == reflect.Ptr, t.Kind() == reflect.Slice, t.Kind() == reflect.Array:
+		return c.readsOptions(t.Elem())
+	case isSQLNullType(t):
+		return c.readsOptions(t.Field(0).Type)
+	}
+	return false
+}
@@ -0,0 +1,136 @@
+package vcsv
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+type weight float64
+
+type registryStruct struct {
+	Weight  weight    `csv:"weight,unit:g"`
+	Date    time.Time `csv:"date,format:02.01.2006"`
+	Ptr     *weight   `csv:"ptr,unit:kg"`
+	Weights []weight  `csv:"weights,split:;,unit:kg"`
+}
+
+func parseWeight(value string, info FieldInfo) (weight, error) {
+	f, err := strconv.ParseFloat(strings.TrimSuffix(value, info.Options["unit"]), 64)
+	if info.Options["unit"] == "g" {
+		f /= 1000
+	}
+	return weight(f), err
+}
+
+func TestWithConverter(t *testing.T) {
+	var dateInfo FieldInfo
+	parseDate := func(value string, info FieldInfo) (time.Time, error) {
+		dateInfo = info
+		if value == "today" {
+			return time.Date(2023, 12, 4, 0, 0, 0, 0, time.UTC), nil
+		}
+		return time.Parse(info.Format, value)
+	}
+
+	csvReader, err := New(strings.NewReader("weight,date,ptr,weights\n1500g,today,2kg,1kg;2kg"),
+		WithConverter(parseWeight), WithConverter(parseDate))
+	MustNoError(t, err)
+
+	csvReader.Next(&err)
+	MustNoError(t, err)
+
+	var result registryStruct
+	MustNoError(t, csvReader.UnmarshalLine(&result))
+
+	two := weight(2)
+	expect := registryStruct{Weight: 1.5, Date: time.Date(2023, 12, 4, 0, 0, 0, 0, time.UTC), Ptr: &two, Weights: []weight{1, 2}}
+	if ok := reflect.DeepEqual(expect, result); !ok {
+		t.Fatalf("Expected %+v but got %+v", expect, result)
+	}
+
+	expectInfo := FieldInfo{Name: "Date", Column: "date", Index: 1, Format: "02.01.2006"}
+	if ok := reflect.DeepEqual(expectInfo, dateInfo); !ok {
+		t.Fatalf("Expected %+v but got %+v", expectInfo, dateInfo)
+	}
+}
+
+func TestRegisterConverter(t *testing.T) {
+	type level int
+	type data struct {
+		Level level `csv:"level"`
+	}
+
+	errInvalidLevel := errors.New("invalid level")
+	RegisterConverter(func(value string, _ FieldInfo) (level, error) {
+		switch value {
+		case "low":
+			return 0, nil
+		case "high":
+			return 1, nil
+		}
+		return 0, errInvalidLevel
+	})
+
+	csvReader, err := New(strings.NewReader("level\nhigh\nmedium"))
+	MustNoError(t, err)
+
+	csvReader.Next(&err)
+	MustNoError(t, err)
+
+	var result data
+	MustNoError(t, csvReader.UnmarshalLine(&result))
+	if result.Level != 1 {
+		t.Fatalf("Expected level 1 but got %d", result.Level)
+	}
+
+	csvReader.Next(&err)
+	MustNoError(t, err)
+
+	if err := csvReader.UnmarshalLine(&result); !errors.Is(err, errInvalidLevel) {
+		t.Fatalf("Expected invalid level error but got %v", err)
+	}
+
+	// the converter of the reader takes precedence
+	csvReader, err = New(strings.NewReader("level\nmedium"), WithConverter(func(string, FieldInfo) (level, error) {
+		return 2, nil
+	}))
+	MustNoError(t, err)
+
+	csvReader.Next(&err)
+	MustNoError(t, err)
+
+	MustNoError(t, csvReader.UnmarshalLine(&result))
+	if result.Level != 2 {
+		t.Fatalf("Expected level 2 but got %d", result.Level)
+	}
+}
+
+func TestUnknownCustomOption(t *testing.T) {
+	tests := []struct {
+		name   string
+		target any
+	}{
+		{name: "Typo", target: &struct {
+			Count int `csv:"count,defualt:5"`
+		}{}},
+		{name: "Without Converter", target: &registryStruct{}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			csvReader, err := New(strings.NewReader("count,weight,date,ptr,weights\n1,1500g,04.12.2023,2kg,1kg"))
+			MustNoError(t, err)
+
+			csvReader.Next(&err)
+			MustNoError(t, err)
+
+			err = csvReader.UnmarshalLine(test.target)
+			if err == nil || !strings.Contains(err.Error(), "unknown tag option") {
+				t.Fatalf("Expected unknown tag option error but got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package vcsv
+
+import "errors"
+
+// RowUnmarshaler is implemented by structs that read values of the CSV line themselves, e.g. values that depend on
+// several columns. CSVReader.UnmarshalLine calls UnmarshalCSVRow after the tagged struct fields were read.
+type RowUnmarshaler interface {
+	UnmarshalCSVRow(row Row) error
+}
+
+// Row gives access to the values of the current CSV line by column name or index.
+type Row struct {
+	r *CSVReader
+}
+
+// Get returns the value of the given column name, see CSVReader.Get.
+func (row Row) Get(columnName string) (string, error) {
+	return row.r.Get(columnName)
+}
+
+// GetByColumnIndex returns the value by the given column index, see CSVReader.GetByColumnIndex.
+func (row Row) GetByColumnIndex(columnIndex int) (string, error) {
+	return row.r.GetByColumnIndex(columnIndex)
+}
+
+// Len returns the number of values of the CSV line.
+func (row Row) Len() int {
+	return len(row.r.columns)
+}
+
+// Header returns the CSV header columns.
+func (row Row) Header() []string {
+	return row.r.Header()
+}
+
+// lineError adds the line and record of the current CSV line to errors of RowUnmarshaler and Validator,
+// that are not a ParseError yet.
+func (r *CSVReader) lineError(err error) error {
+	var parseErr *ParseError
+	if errors.As(err, &parseErr) {
+		return err
+	}
+	return &ParseError{
+		Line:        r.CurrentLineIndex(),
+		Record:      r.CurrentRecordIndex(),
+		ColumnIndex: -1,
+		Err:         err,
+	}
+}
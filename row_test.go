@@ -0,0 +1,78 @@
+package vcsv
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+type rowStruct struct {
+	Name  string `csv:"name"`
+	Total float64
+}
+
+func (s *rowStruct) UnmarshalCSVRow(row Row) error {
+	for i, column := range row.Header() {
+		if !strings.HasPrefix(column, "amount_") {
+			continue
+		}
+
+		value, err := row.GetByColumnIndex(i)
+		if err != nil {
+			return err
+		}
+		amount, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return err
+		}
+		s.Total += amount
+	}
+	return nil
+}
+
+func TestRowUnmarshaler(t *testing.T) {
+	csvReader, err := New(strings.NewReader("name,amount_1,amount_2\nalice,1.5,2\nbob,1,x"))
+	MustNoError(t, err)
+
+	csvReader.Next(&err)
+	MustNoError(t, err)
+
+	var result rowStruct
+	MustNoError(t, csvReader.UnmarshalLine(&result))
+	if expect := (rowStruct{Name: "alice", Total: 3.5}); expect != result {
+		t.Fatalf("Expected %+v but got %+v", expect, result)
+	}
+
+	csvReader.Next(&err)
+	MustNoError(t, err)
+
+	result = rowStruct{}
+	err = csvReader.UnmarshalLine(&result)
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) || parseErr.Line != 3 || parseErr.Record != 1 || !errors.Is(err, strconv.ErrSyntax) {
+		t.Fatalf("Expected parse error in line 3 but got %v", err)
+	}
+	if result.Name != "bob" {
+		t.Fatalf("Expected the tagged fields to be read before UnmarshalCSVRow but got %+v", result)
+	}
+}
+
+func TestRowUnmarshalerCollectErrors(t *testing.T) {
+	type data struct {
+		rowStruct
+		Age int `csv:"age"`
+	}
+
+	csvReader, err := New(strings.NewReader("name,age,amount_1\nalice,x,y"), WithCollectErrors())
+	MustNoError(t, err)
+
+	csvReader.Next(&err)
+	MustNoError(t, err)
+
+	var result data
+	err = csvReader.UnmarshalLine(&result)
+	if joined, ok := err.(interface{ Unwrap() []error }); !ok || len(joined.Unwrap()) != 2 {
+		t.Fatalf("Expected 2 joined errors but got %v", err)
+	}
+}
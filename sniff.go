@@ -0,0 +1,221 @@
+package vcsv
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const (
+	// sniffSampleSize is the number of bytes that are looked at by Sniff.
+	sniffSampleSize = 64 * 1024
+	// sniffMaxRecords is the maximum number of records that are compared by Sniff.
+	sniffMaxRecords = 20
+)
+
+// sniffSeparators are the separation characters detected by Sniff, in the order of their preference.
+var sniffSeparators = []rune{',', ';', '\t', '|'}
+
+// Dialect describes the format of CSV data.
+type Dialect struct {
+	Separator      rune   // The separation character, one of ',', ';', '\t' or '|'.
+	Quoted         bool   // Whether values are enclosed in quotes.
+	HasHeader      bool   // Whether the first line is a header, false only if the lines give evidence against it.
+	LineTerminator string // The line terminator, "\n", "\r\n" or "\r".
+}
+
+// Sniff detects the dialect of the CSV data in r by looking at the first lines.
+// The returned reader still contains all data of r and should be used instead of r.
+// The detected dialect can be passed to New with the WithDialect option, or use the WithAutoDetect option instead.
+func Sniff(r io.Reader) (Dialect, io.Reader, error) {
+	br := bufio.NewReaderSize(r, sniffSampleSize)
+	sample, err := br.Peek(sniffSampleSize)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, bufio.ErrBufferFull) {
+		return Dialect{}, nil, err
+	}
+
+	// only look at complete lines, unless the sample contains all data
+	if len(sample) == sniffSampleSize {
+		if i := bytes.LastIndexAny(sample, "\r\n"); i > 0 {
+			sample = sample[:i+1]
+		}
+	}
+
+	d := Dialect{Separator: ',', LineTerminator: sniffLineTerminator(sample)}
+
+	var records [][]string
+	bestScore := sniffScore{}
+	for _, separator := range sniffSeparators {
+		candidate := sniffRecords(sample, separator)
+		if score := scoreRecords(candidate); score.better(bestScore) {
+			bestScore = score
+			d.Separator = separator
+			records = candidate
+		}
+	}
+
+	if records == nil {
+		// single column data, no separator splits the lines into several fields
+		records = sniffRecords(sample, d.Separator)
+	}
+
+	d.Quoted = sniffQuoted(sample, d.Separator)
+	d.HasHeader = sniffHeader(records)
+	return d, br, nil
+}
+
+func sniffLineTerminator(sample []byte) string {
+	i := bytes.IndexAny(sample, "\r\n")
+	switch {
+	case i < 0, sample[i] == '\n':
+		return "\n"
+	case i+1 < len(sample) && sample[i+1] == '\n':
+		return "\r\n"
+	default:
+		return "\r"
+	}
+}
+
+func sniffRecords(sample []byte, separator rune) [][]string {
+	reader := csv.NewReader(bytes.NewReader(sample))
+	reader.Comma = separator
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	var records [][]string
+	for len(records) < sniffMaxRecords {
+		record, err := reader.Read()
+		if err != nil {
+			break
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+// sniffScore rates how likely the records were split with the right separation character.
+type sniffScore struct {
+	consistent int // number of records with the most common number of fields
+	fields     int // most common number of fields
+}
+
+func (s sniffScore) better(other sniffScore) bool {
+	if s.fields < 2 {
+		return false
+	}
+	if s.consistent != other.consistent {
+		return s.consistent > other.consistent
+	}
+	return s.fields > other.fields
+}
+
+func scoreRecords(records [][]string) sniffScore {
+	counts := make(map[int]int)
+	var score sniffScore
+	for _, record := range records {
+		counts[len(record)]++
+		n := counts[len(record)]
+		if n > score.consistent || (n == score.consistent && len(record) > score.fields) {
+			score = sniffScore{consistent: n, fields: len(record)}
+		}
+	}
+	return score
+}
+
+// sniffQuoted reports whether a value at the beginning of a line or after a separation character starts with a quote.
+func sniffQuoted(sample []byte, separator rune) bool {
+	return bytes.HasPrefix(sample, []byte{'"'}) ||
+		bytes.Contains(sample, []byte(string(separator)+`"`)) ||
+		bytes.Contains(sample, []byte("\n\""))
+}
+
+// sniffHeader guesses whether the first record is a header. Every column votes for a header if the type, the kind of
+// characters or the length of the first value differs from the values of the other records, and against a header if
+// it is the same. The first record is a header unless there is evidence against it, like an empty or numeric value
+// in the first record or more columns voting against a header than for it.
+func sniffHeader(records [][]string) bool {
+	if len(records) == 0 {
+		return true
+	}
+
+	header := records[0]
+	for _, name := range header {
+		if name == "" || isNumber(name) {
+			return false
+		}
+	}
+
+	votes := 0
+	for column, name := range header {
+		numeric, sameLength := true, true
+		length := -1
+		values := 0
+		var classes charClasses
+		for _, record := range records[1:] {
+			if column >= len(record) || record[column] == "" {
+				continue
+			}
+
+			value := record[column]
+			values++
+			numeric = numeric && isNumber(value)
+			classes = classes.union(charClassesOf(value))
+			if length >= 0 && length != len(value) {
+				sameLength = false
+			}
+			length = len(value)
+		}
+
+		switch {
+		case values == 0:
+			continue
+		case numeric:
+			votes++
+		case !classes.subsetOf(charClassesOf(name)):
+			votes++
+		case sameLength && length != len(name):
+			votes++
+		case sameLength:
+			votes--
+		}
+	}
+	return votes >= 0
+}
+
+// charClasses are the kinds of characters of a value, besides letters.
+type charClasses struct {
+	digits      bool
+	punctuation bool
+}
+
+func charClassesOf(value string) charClasses {
+	var c charClasses
+	for _, r := range value {
+		switch {
+		case unicode.IsDigit(r):
+			c.digits = true
+		case !unicode.IsLetter(r) && !unicode.IsSpace(r) && r != '_' && r != '-':
+			c.punctuation = true
+		}
+	}
+	return c
+}
+
+func (c charClasses) union(other charClasses) charClasses {
+	return charClasses{digits: c.digits || other.digits, punctuation: c.punctuation || other.punctuation}
+}
+
+func (c charClasses) subsetOf(other charClasses) bool {
+	return (!c.digits || other.digits) && (!c.punctuation || other.punctuation)
+}
+
+func isNumber(value string) bool {
+	value = strings.TrimSpace(value)
+	_, err := strconv.ParseFloat(strings.Replace(value, ",", ".", 1), 64)
+	return err == nil
+}
@@ -0,0 +1,122 @@
+package vcsv
+
+import (
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSniff(t *testing.T) {
+	testCases := []struct {
+		name    string
+		csvData string
+		expect  Dialect
+	}{
+		{
+			name:    "Comma With Header",
+			csvData: "name,age,city\nalice,30,Köln\nbob,31,Berlin\n",
+			expect:  Dialect{Separator: ',', HasHeader: true, LineTerminator: "\n"},
+		},
+		{
+			name:    "Semicolon With Decimal Comma",
+			csvData: "name;amount;date\r\nalice;1,5;2023-12-04\r\nbob;22,75;2023-12-05\r\n",
+			expect:  Dialect{Separator: ';', HasHeader: true, LineTerminator: "\r\n"},
+		},
+		{
+			name:    "Tab Without Header",
+			csvData: "alice\t30\t2023-12-04\nbob\t31\t2023-12-05\n",
+			expect:  Dialect{Separator: '\t', HasHeader: false, LineTerminator: "\n"},
+		},
+		{
+			name:    "Pipe Quoted",
+			csvData: "\"name\"|\"comment\"\n\"alice\"|\"a, b; c\"\n\"bob\"|\"d, e; f\"\n",
+			expect:  Dialect{Separator: '|', Quoted: true, HasHeader: true, LineTerminator: "\n"},
+		},
+		{
+			name:    "Single Column",
+			csvData: "name\nalice\nbob",
+			expect:  Dialect{Separator: ',', HasHeader: true, LineTerminator: "\n"},
+		},
+		{
+			name:    "Values Of Different Lengths",
+			csvData: "name;city\nalice;berlin\nbob;köln",
+			expect:  Dialect{Separator: ';', HasHeader: true, LineTerminator: "\n"},
+		},
+		{
+			name:    "Values Like The First Line",
+			csvData: "alice;bob\ncarol;tom\nsusan;ann",
+			expect:  Dialect{Separator: ';', HasHeader: false, LineTerminator: "\n"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dialect, r, err := Sniff(strings.NewReader(tc.csvData))
+			MustNoError(t, err)
+
+			if ok := reflect.DeepEqual(tc.expect, dialect); !ok {
+				t.Fatalf("Expected %+v but got %+v", tc.expect, dialect)
+			}
+
+			data, err := io.ReadAll(r)
+			MustNoError(t, err)
+			if string(data) != tc.csvData {
+				t.Fatalf("Expected the reader to contain %q but got %q", tc.csvData, data)
+			}
+		})
+	}
+}
+
+func TestAutoDetect(t *testing.T) {
+	type byName struct {
+		Name string `csv:"name"`
+		Age  int    `csv:"age"`
+	}
+	type byIndex struct {
+		Name string `csv:"index:0"`
+		Age  int    `csv:"index:1"`
+	}
+
+	t.Run("With Header", func(t *testing.T) {
+		csvReader, err := New(strings.NewReader("name;age\nalice;30"), WithAutoDetect())
+		MustNoError(t, err)
+
+		csvReader.Next(&err)
+		MustNoError(t, err)
+
+		var result byName
+		MustNoError(t, csvReader.UnmarshalLine(&result))
+		if expect := (byName{Name: "alice", Age: 30}); expect != result {
+			t.Fatalf("Expected %+v but got %+v", expect, result)
+		}
+	})
+
+	t.Run("Without Header", func(t *testing.T) {
+		csvReader, err := New(strings.NewReader("alice|30\nbob|31"), WithAutoDetect())
+		MustNoError(t, err)
+
+		csvReader.Next(&err)
+		MustNoError(t, err)
+
+		var result byIndex
+		MustNoError(t, csvReader.UnmarshalLine(&result))
+		if expect := (byIndex{Name: "alice", Age: 30}); expect != result {
+			t.Fatalf("Expected %+v but got %+v", expect, result)
+		}
+	})
+
+	t.Run("Options Take Precedence", func(t *testing.T) {
+		csvReader, err := New(strings.NewReader("alice;30\nbob;31"), WithAutoDetect(), WithHeader([]string{"name", "age"}))
+		MustNoError(t, err)
+
+		csvReader.Next(&err)
+		MustNoError(t, err)
+
+		var result byName
+		MustNoError(t, csvReader.UnmarshalLine(&result))
+		if expect := (byName{Name: "alice", Age: 30}); expect != result {
+			t.Fatalf("Expected %+v but got %+v", expect, result)
+		}
+	})
+}
@@ -3,73 +3,133 @@ package vcsv
 import (
 	"fmt"
 	"reflect"
+	"regexp"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
 )
 
-type fieldContext struct {
+// taggedField is a struct field with its parsed `csv` tag. Fields of nested structs are flattened,
+// so index is the index sequence of the field and name is its dotted path, e.g. "Billing.Street".
+type taggedField struct {
+	index       []int
+	name        string
 	structField reflect.StructField
-	rv          reflect.Value
 	tagOpts     tagOptions
 }
 
-func (r *CSVReader) parseFieldValue(structField reflect.StructField, rv reflect.Value) error {
-	tagOpts, err := readTag(structField.Tag)
-	if err != nil {
-		return err
-	}
-	if tagOpts == nil {
-		return nil
-	}
-	fc := fieldContext{structField: structField, rv: rv, tagOpts: *tagOpts}
-	return r.handleFieldByTagOptions(fc)
-}
+// taggedFieldsCache caches the tagged fields by struct type, because tags never change at runtime.
+var taggedFieldsCache sync.Map // map[reflect.Type][]taggedField
 
-func (r *CSVReader) handleFieldByTagOptions(fc fieldContext) error {
-	if fc.tagOpts.columnName != "" {
-		return r.handleFieldByName(fc.tagOpts.columnName, fc)
+// cachedTaggedFields returns the tagged fields of the given struct type and parses them only once per type.
+func cachedTaggedFields(rt reflect.Type) ([]taggedField, error) {
+	if fields, ok := taggedFieldsCache.Load(rt); ok {
+		return fields.([]taggedField), nil
 	}
 
-	if fc.tagOpts.index >= 0 {
-		return r.handleFieldByIndex(fc.tagOpts.index, fc)
+	fields, err := readTaggedFields(rt)
+	if err != nil {
+		return nil, err
 	}
+	taggedFieldsCache.Store(rt, fields)
+	return fields, nil
+}
 
-	return nil
+func readTaggedFields(rt reflect.Type) ([]taggedField, error) {
+	return appendTaggedFields(nil, rt, nil, "", "", nil)
 }
 
-func (r *CSVReader) handleFieldByName(columnName string, fc fieldContext) error {
-	value, err := r.Get(columnName)
-	if err != nil {
-		return err
+// appendTaggedFields appends the tagged fields of rt. The fields of embedded structs without a tag and of structs
+// with the `inline` tag option are appended recursively, with the column name of the inline tag as prefix.
+func appendTaggedFields(fields []taggedField, rt reflect.Type, index []int, name, prefix string, parents []reflect.Type) ([]taggedField, error) {
+	if slices.Contains(parents, rt) {
+		return nil, fmt.Errorf("recursive inline struct %s", rt)
 	}
+	parents = append(parents, rt)
 
-	return r.setFieldValue(value, fc)
-}
+	for i := 0; i < rt.NumField(); i++ {
+		structField := rt.Field(i)
+		if structField.Tag.Get("csv") == "-" {
+			continue
+		}
 
-func (r *CSVReader) handleFieldByIndex(index int, fc fieldContext) error {
-	if index >= len(r.columns) {
-		return fmt.Errorf("index %d out of range for field %s [%s]", index, fc.structField.Name, fc.structField.Tag)
-	}
+		tagOpts, err := readTag(structField.Tag)
+		if err != nil {
+			return nil, fmt.Errorf("invalid tag of field %s%s [%s]: %w", name, structField.Name, structField.Tag, err)
+		}
+
+		fieldIndex := append(index[:len(index):len(index)], i)
+		fieldName := name + structField.Name
+		if inlineType, ok := inlineStructType(structField, tagOpts); ok {
+			inlinePrefix := prefix
+			if tagOpts != nil {
+				inlinePrefix += tagOpts.columnName
+			}
+			fields, err = appendTaggedFields(fields, inlineType, fieldIndex, fieldName+".", inlinePrefix, parents)
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
 
-	value := r.columns[index]
-	return r.setFieldValue(value, fc)
+		if tagOpts == nil {
+			continue
+		}
+		if prefix != "" && tagOpts.columnName != "" {
+			for j := range tagOpts.columnNames {
+				tagOpts.columnNames[j] = prefix + tagOpts.columnNames[j]
+			}
+			tagOpts.columnName = tagOpts.columnNames[0]
+		}
+		if prefix != "" && tagOpts.prefix != "" {
+			tagOpts.prefix = prefix + tagOpts.prefix
+		}
+		fields = append(fields, taggedField{index: fieldIndex, name: fieldName, structField: structField, tagOpts: *tagOpts})
+	}
+	return fields, nil
 }
 
-func (r *CSVReader) setFieldValue(value string, fc fieldContext) error {
-	convertedValue, err := convertToType(value, fc.structField, fc.tagOpts)
-	if err != nil {
-		return fmt.Errorf("failed to convert value %s to type %s in field %s [%s]: %w",
-			value, fc.structField.Type.Kind(), fc.structField.Name, fc.structField.Tag, err)
+// inlineStructType returns the struct type of fields with the `inline` tag option and of embedded structs without tag.
+func inlineStructType(structField reflect.StructField, tagOpts *tagOptions) (reflect.Type, bool) {
+	t := structField.Type
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil, false
 	}
 
-	fc.rv.Set(convertedValue)
-	return nil
+	if tagOpts != nil {
+		return t, tagOpts.inline
+	}
+	return t, structField.Anonymous
 }
 
 type tagOptions struct {
-	columnName string
-	index      int
-	format     string
+	columnName  string
+	columnNames []string // columnName and its alternatives
+	index       int
+	indexEnd    int    // last index of a column range, inclusive
+	prefix      string // column name prefix of repeated columns
+	occurrence  int
+	format      string
+	split       string
+	inline      bool
+	extra       bool
+	line        bool // metadata: the current line index
+	record      bool // metadata: the current record index
+	raw         bool // metadata: the raw text of the current record
+	defaultVal  *string
+	nullValues  []string
+	required    bool
+	min         *float64
+	max         *float64
+	minLen      int
+	maxLen      int
+	oneOf       []string
+	pattern     *regexp.Regexp
+	custom      map[string]string // other `<key>:<value>` options, passed to custom converters
 }
 
 func readTag(tag reflect.StructTag) (*tagOptions, error) {
@@ -78,39 +138,133 @@ func readTag(tag reflect.StructTag) (*tagOptions, error) {
 		return nil, nil
 	}
 
-	t := &tagOptions{index: -1} // declare index as -1 to indicate that it was not set
-	for _, opt := range strings.Split(tv, ",") {
-		if err := parseTagOption(opt, t); err != nil {
+	t := &tagOptions{index: -1, indexEnd: -1, minLen: -1, maxLen: -1} // declare -1 to indicate that it was not set
+	opts := strings.Split(tv, ",")
+	for i, opt := range opts {
+		// the pattern option is the last option, so its regular expression may contain commas
+		pattern := strings.HasPrefix(strings.TrimSpace(opt), "pattern:")
+		if pattern {
+			opt = strings.Join(opts[i:], ",")
+		}
+		if err := parseTagOption(opt, i == 0, t); err != nil {
 			return nil, err
 		}
+		if pattern {
+			break
+		}
 	}
 
 	return t, nil
 }
 
-func parseTagOption(opt string, tag *tagOptions) (err error) {
+// parseTagOption parses a single tag option. Flags like `required` are only recognized after the first option,
+// so the first option may always be a column name. Unknown flags after the first option are an error.
+func parseTagOption(opt string, first bool, tag *tagOptions) (err error) {
 	opt = strings.TrimSpace(opt)
 
 	switch {
 	case strings.HasPrefix(opt, "index:"):
-		tag.index, err = parseIndex(opt)
-		if err != nil {
-			return err
-		}
+		tag.index, tag.indexEnd, err = parseIndex(opt)
+	case strings.HasPrefix(opt, "prefix:"):
+		tag.prefix = opt[7:] // remove `prefix:`
+	case strings.HasPrefix(opt, "occurrence:"):
+		tag.occurrence, err = strconv.Atoi(opt[11:]) // remove `occurrence:`
 	case strings.HasPrefix(opt, "format:"):
 		tag.format = parseFormat(opt)
+	case strings.HasPrefix(opt, "split:"):
+		tag.split = opt[6:] // remove `split:`
+	case strings.HasPrefix(opt, "default:"):
+		tag.defaultVal = parseDefault(opt)
+	case strings.HasPrefix(opt, "null:"):
+		tag.nullValues = strings.Split(opt[5:], "|") // remove `null:`
+	case strings.HasPrefix(opt, "min:"):
+		tag.min, err = parseLimit(opt[4:]) // remove `min:`
+	case strings.HasPrefix(opt, "max:"):
+		tag.max, err = parseLimit(opt[4:]) // remove `max:`
+	case strings.HasPrefix(opt, "minlen:"):
+		tag.minLen, err = strconv.Atoi(opt[7:]) // remove `minlen:`
+	case strings.HasPrefix(opt, "maxlen:"):
+		tag.maxLen, err = strconv.Atoi(opt[7:]) // remove `maxlen:`
+	case strings.HasPrefix(opt, "oneof:"):
+		tag.oneOf = strings.Split(opt[6:], "|") // remove `oneof:`
+	case strings.HasPrefix(opt, "pattern:"):
+		tag.pattern, err = parsePattern(opt)
+	case !first && opt == "required":
+		tag.required = true
+	case !first && opt == "inline":
+		tag.inline = true
+	case !first && opt == "extra":
+		tag.extra = true
+	case !first && opt == "line":
+		tag.line = true
+	case !first && opt == "record":
+		tag.record = true
+	case !first && opt == "raw":
+		tag.raw = true
+	case !first && strings.Contains(opt, ":"):
+		key, value, _ := strings.Cut(opt, ":")
+		if tag.custom == nil {
+			tag.custom = make(map[string]string)
+		}
+		tag.custom[key] = value
+	case !first:
+		err = fmt.Errorf("unknown tag option \"%s\"", opt)
 	default:
-		tag.columnName = opt
+		tag.columnNames = strings.Split(opt, "|")
+		tag.columnName = tag.columnNames[0]
 	}
 
-	return nil
+	return err
 }
 
-func parseIndex(opt string) (int, error) {
+// parseIndex parses a column index or a column range like `index:5-16`. The end is -1 for a single column index.
+func parseIndex(opt string) (int, int, error) {
 	opt = opt[6:] // remove `index:`
-	return strconv.Atoi(opt)
+	start, end, isRange := strings.Cut(opt, "-")
+	index, err := strconv.Atoi(start)
+	if err != nil || !isRange {
+		return index, -1, err
+	}
+
+	indexEnd, err := strconv.Atoi(end)
+	if err != nil {
+		return index, -1, err
+	}
+	if indexEnd < index {
+		return index, -1, fmt.Errorf("invalid column range %s", opt)
+	}
+	return index, indexEnd, nil
+}
+
+// metadata reports whether the field is filled with metadata of the current record instead of a column value.
+func (t tagOptions) metadata() bool {
+	return t.line || t.record || t.raw
+}
+
+// multiColumn reports whether the field is a slice or array bound to a column range or to repeated columns.
+func (t tagOptions) multiColumn() bool {
+	return t.indexEnd >= 0 || t.prefix != ""
 }
 
 func parseFormat(opt string) string {
 	return opt[7:] // remove `format:`
 }
+
+func parseDefault(opt string) *string {
+	opt = opt[8:] // remove `default:`
+	return &opt
+}
+
+func parseLimit(opt string) (*float64, error) {
+	limit, err := strconv.ParseFloat(opt, 64)
+	if err != nil {
+		return nil, err
+	}
+	return &limit, nil
+}
+
+// parsePattern compiles the regular expression of the `pattern` option. The expression must match the whole value.
+func parsePattern(opt string) (*regexp.Regexp, error) {
+	opt = opt[8:] // remove `pattern:`
+	return regexp.Compile("^(?:" + opt + ")$")
+}
@@ -0,0 +1,112 @@
+package vcsv
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"reflect"
+)
+
+// Reader is a typed CSV reader that reads every CSV line into a new value of the struct type T.
+// It is built on top of the CSVReader and supports the same options and struct tags.
+type Reader[T any] struct {
+	csv *CSVReader
+}
+
+// NewReader creates a new Reader for the struct type T.
+// If the CSV data has a header, it is checked against T with CSVReader.Validate.
+func NewReader[T any](r io.Reader, options ...Option) (*Reader[T], error) {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	if t.Kind() != reflect.Struct {
+		return nil, errors.New("T must be a struct")
+	}
+
+	csvReader, err := New(r, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	if csvReader.header != nil {
+		if err := csvReader.Validate(t); err != nil {
+			return nil, err
+		}
+	}
+	return &Reader[T]{csv: csvReader}, nil
+}
+
+// Header returns the CSV header columns.
+func (r *Reader[T]) Header() []string {
+	return r.csv.Header()
+}
+
+// All returns an iterator over the remaining CSV lines. Every line is unmarshalled into a fresh T,
+// so values of previous lines never leak into the next one.
+//
+// If a line cannot be unmarshalled, the error is yielded together with the zero value of T and the
+// iteration continues with the next line. The error is usually a *ParseError that carries the line number,
+// other errors are prefixed with it. If the CSV data itself cannot be read, the error is yielded and the
+// iteration stops. Breaking out of the loop stops reading, a later call
+// of All continues with the next line.
+//
+// Example:
+//
+//	for p, err := range reader.All() {
+//		if err != nil {
+//			return err
+//		}
+//		fmt.Printf("%+v\n", p)
+//	}
+func (r *Reader[T]) All() iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var err error
+		for r.csv.Next(&err) {
+			var v T
+			if err := r.csv.UnmarshalLine(&v); err != nil {
+				var parseErr *ParseError
+				if !errors.As(err, &parseErr) {
+					err = fmt.Errorf("line %d: %w", r.csv.CurrentLineIndex(), err)
+				}
+
+				var zero T
+				if !yield(zero, err) {
+					return
+				}
+				continue
+			}
+
+			if !yield(v, nil) {
+				return
+			}
+		}
+
+		if err != nil {
+			var zero T
+			yield(zero, err)
+		}
+	}
+}
+
+// ReadAll reads all remaining CSV lines. It stops at the first error and returns the lines read so far.
+// If the WithCollectErrors option is set, lines with errors are skipped and all errors are returned together,
+// joined by errors.Join, up to the limit set by the WithMaxErrors option.
+func (r *Reader[T]) ReadAll() ([]T, error) {
+	var values []T
+	var errs []error
+	for v, err := range r.All() {
+		if err == nil {
+			values = append(values, v)
+			continue
+		}
+
+		if !r.csv.collectErrors {
+			return values, err
+		}
+		if r.csv.maxErrors > 0 && len(errs) >= r.csv.maxErrors {
+			errs = append(errs, ErrTooManyErrors)
+			break
+		}
+		errs = append(errs, err)
+	}
+	return values, errors.Join(errs...)
+}
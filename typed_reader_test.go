@@ -0,0 +1,136 @@
+package vcsv
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+type typedTestStruct struct {
+	Name string `csv:"name"`
+	Age  int    `csv:"age"`
+}
+
+func TestReaderAll(t *testing.T) {
+	csvData := "name,age\nalice,30\nbob\ncarol,notanint\ndave,40"
+	reader, err := NewReader[typedTestStruct](strings.NewReader(csvData))
+	MustNoError(t, err)
+
+	var got []typedTestStruct
+	var errs []error
+	for v, err := range reader.All() {
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		got = append(got, v)
+	}
+
+	expect := []typedTestStruct{{Name: "alice", Age: 30}, {Name: "dave", Age: 40}}
+	if len(errs) != 2 {
+		t.Fatalf("Expected 2 errors but got %v", errs)
+	}
+	for i, line := range []int{3, 4} {
+		var parseErr *ParseError
+		if !errors.As(errs[i], &parseErr) || parseErr.Line != line {
+			t.Fatalf("Expected error for line %d but got %v", line, errs[i])
+		}
+	}
+	if ok := reflect.DeepEqual(expect, got); !ok {
+		t.Fatalf("Expected %+v but got %+v", expect, got)
+	}
+}
+
+func TestReaderAllBreak(t *testing.T) {
+	csvData := "name,age\nalice,30\nbob,31\ncarol,32"
+	reader, err := NewReader[typedTestStruct](strings.NewReader(csvData))
+	MustNoError(t, err)
+
+	for v, err := range reader.All() {
+		MustNoError(t, err)
+		if v.Name != "alice" {
+			t.Fatalf("Expected alice but got %+v", v)
+		}
+		break
+	}
+
+	var got []string
+	for v, err := range reader.All() {
+		MustNoError(t, err)
+		got = append(got, v.Name)
+	}
+	if expect := []string{"bob", "carol"}; !reflect.DeepEqual(expect, got) {
+		t.Fatalf("Expected %v but got %v", expect, got)
+	}
+}
+
+func TestReaderAllReadError(t *testing.T) {
+	readErr := errors.New("read failed")
+	r := io.MultiReader(strings.NewReader("name,age\nalice,30\n"), iotest.ErrReader(readErr))
+	reader, err := NewReader[typedTestStruct](r)
+	MustNoError(t, err)
+
+	var lines int
+	var lastErr error
+	for _, err := range reader.All() {
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		lines++
+	}
+
+	if lines != 1 || !errors.Is(lastErr, readErr) {
+		t.Fatalf("Expected 1 line and the read error but got %d lines and %v", lines, lastErr)
+	}
+}
+
+func TestNewReaderRequiresStruct(t *testing.T) {
+	_, err := NewReader[int](strings.NewReader("name,age"))
+	MustError(t, err)
+}
+
+func TestReaderReadAll(t *testing.T) {
+	csvData := "name,age\nalice,30\nbob,x\ncarol,y\ndave,40\neve,z\nfrank,50"
+
+	testCases := []struct {
+		name       string
+		options    []Option
+		expect     []string
+		expectErrs int
+		tooMany    bool
+	}{
+		{name: "Stop At First Error", expect: []string{"alice"}, expectErrs: 1},
+		{name: "Collect Errors", options: []Option{WithCollectErrors()}, expect: []string{"alice", "dave", "frank"}, expectErrs: 3},
+		{name: "Collect Errors With Limit", options: []Option{WithCollectErrors(), WithMaxErrors(2)}, expect: []string{"alice", "dave"}, expectErrs: 3, tooMany: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			reader, err := NewReader[typedTestStruct](strings.NewReader(csvData), tc.options...)
+			MustNoError(t, err)
+
+			values, err := reader.ReadAll()
+			MustError(t, err)
+
+			var got []string
+			for _, v := range values {
+				got = append(got, v.Name)
+			}
+			if !reflect.DeepEqual(tc.expect, got) {
+				t.Fatalf("Expected %v but got %v", tc.expect, got)
+			}
+
+			errs := []error{err}
+			if joined, ok := err.(interface{ Unwrap() []error }); ok {
+				errs = joined.Unwrap()
+			}
+			if len(errs) != tc.expectErrs || errors.Is(err, ErrTooManyErrors) != tc.tooMany {
+				t.Fatalf("Expected %d errors (too many: %v) but got %v", tc.expectErrs, tc.tooMany, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,177 @@
+package vcsv
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ErrValidation is returned when a CSV value does not satisfy a validation option of its struct field.
+var ErrValidation = errors.New("validation failed")
+
+// Validator is implemented by structs that check rules across several fields, e.g. that an end date is after the
+// start date. CSVReader.UnmarshalLine calls Validate after all fields of a CSV line were read without errors.
+type Validator interface {
+	Validate() error
+}
+
+// validationError wraps errors of Validator with ErrValidation, like the errors of the validation tag options.
+func validationError(err error) error {
+	if errors.Is(err, ErrValidation) {
+		return err
+	}
+	return fmt.Errorf("%w: %w", ErrValidation, err)
+}
+
+// validatorFunc validates a CSV value after it was converted and set on the field value rv.
+type validatorFunc func(value string, rv reflect.Value) error
+
+// newValidators returns the validators of the validation tag options `min`, `max`, `minlen`, `maxlen`, `oneof`
+// and `pattern`. The `required` option is checked before the conversion, see validateRequired.
+func newValidators(t reflect.Type, tagOpts tagOptions) ([]validatorFunc, error) {
+	var validators []validatorFunc
+
+	if tagOpts.min != nil || tagOpts.max != nil {
+		if !isNumeric(nullableElem(t)) {
+			return nil, fmt.Errorf("options min and max are not supported for type %s", t.Kind())
+		}
+		validators = append(validators, validateRange(tagOpts.min, tagOpts.max))
+	}
+
+	if tagOpts.minLen >= 0 || tagOpts.maxLen >= 0 {
+		if !hasLength(nullableElem(t)) {
+			return nil, fmt.Errorf("options minlen and maxlen are not supported for type %s", t.Kind())
+		}
+		validators = append(validators, validateLength(tagOpts.minLen, tagOpts.maxLen))
+	}
+
+	if tagOpts.oneOf != nil {
+		validators = append(validators, func(value string, _ reflect.Value) error {
+			if !slices.Contains(tagOpts.oneOf, value) {
+				return fmt.Errorf("%w: value must be one of %s", ErrValidation, strings.Join(tagOpts.oneOf, ", "))
+			}
+			return nil
+		})
+	}
+
+	if tagOpts.pattern != nil {
+		validators = append(validators, func(value string, _ reflect.Value) error {
+			if !tagOpts.pattern.MatchString(value) {
+				return fmt.Errorf("%w: value must match pattern %s", ErrValidation, tagOpts.pattern)
+			}
+			return nil
+		})
+	}
+
+	return validators, nil
+}
+
+func validateRequired(value string) error {
+	if value == "" {
+		return fmt.Errorf("%w: value is required", ErrValidation)
+	}
+	return nil
+}
+
+func validateRange(min, max *float64) validatorFunc {
+	return func(_ string, rv reflect.Value) error {
+		n, ok := numericValue(rv)
+		if !ok {
+			return nil
+		}
+		if min != nil && n < *min {
+			return fmt.Errorf("%w: value must be at least %s", ErrValidation, formatLimit(*min))
+		}
+		if max != nil && n > *max {
+			return fmt.Errorf("%w: value must be at most %s", ErrValidation, formatLimit(*max))
+		}
+		return nil
+	}
+}
+
+func validateLength(minLen, maxLen int) validatorFunc {
+	return func(_ string, rv reflect.Value) error {
+		n, ok := lengthOf(rv)
+		if !ok {
+			return nil
+		}
+		if minLen >= 0 && n < minLen {
+			return fmt.Errorf("%w: length must be at least %d", ErrValidation, minLen)
+		}
+		if maxLen >= 0 && n > maxLen {
+			return fmt.Errorf("%w: length must be at most %d", ErrValidation, maxLen)
+		}
+		return nil
+	}
+}
+
+func isNumeric(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+func hasLength(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.String, reflect.Slice, reflect.Array, reflect.Map:
+		return true
+	}
+	return false
+}
+
+// numericValue returns the value of a numeric field. It returns false for null values.
+func numericValue(rv reflect.Value) (float64, bool) {
+	rv, ok := indirect(rv)
+	if !ok {
+		return 0, false
+	}
+
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	default:
+		return rv.Float(), true
+	}
+}
+
+// lengthOf returns the number of characters of a string field or the number of elements of other fields.
+// It returns false for null values.
+func lengthOf(rv reflect.Value) (int, bool) {
+	rv, ok := indirect(rv)
+	if !ok {
+		return 0, false
+	}
+
+	if rv.Kind() == reflect.String {
+		return utf8.RuneCountInString(rv.String()), true
+	}
+	return rv.Len(), true
+}
+
+// indirect returns the value of pointers and database/sql nullable types. It returns false for null values.
+func indirect(rv reflect.Value) (reflect.Value, bool) {
+	switch {
+	case rv.Kind() == reflect.Ptr:
+		if rv.IsNil() {
+			return rv, false
+		}
+		return rv.Elem(), true
+	case isSQLNullType(rv.Type()):
+		return rv.Field(0), rv.Field(1).Bool()
+	}
+	return rv, true
+}
+
+func formatLimit(limit float64) string {
+	return strconv.FormatFloat(limit, 'f', -1, 64)
+}
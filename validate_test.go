@@ -0,0 +1,177 @@
+package vcsv
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidationTags(t *testing.T) {
+	type data struct {
+		Name     string  `csv:"name,required,minlen:2,maxlen:5"`
+		Quantity int     `csv:"quantity,min:1,max:100"`
+		Price    float64 `csv:"price,min:0.5"`
+		Country  string  `csv:"country,oneof:DE|AT|CH"`
+		Zip      string  `csv:"zip,pattern:[0-9]{5}"`
+	}
+
+	testCases := []struct {
+		name      string
+		row       string
+		expectErr string
+	}{
+		{name: "Valid Data", row: "alice,10,1.5,DE,12345"},
+		{name: "Empty Optional Values", row: "bob,10,1,,"},
+		{name: "Required", row: ",10,1,DE,12345", expectErr: "Name"},
+		{name: "Min Length", row: "a,10,1,DE,12345", expectErr: "Name"},
+		{name: "Max Length", row: "alexander,10,1,DE,12345", expectErr: "Name"},
+		{name: "Min", row: "alice,0,1,DE,12345", expectErr: "Quantity"},
+		{name: "Max", row: "alice,101,1,DE,12345", expectErr: "Quantity"},
+		{name: "Min Float", row: "alice,10,0.4,DE,12345", expectErr: "Price"},
+		{name: "One Of", row: "alice,10,1,FR,12345", expectErr: "Country"},
+		{name: "Pattern", row: "alice,10,1,DE,123456", expectErr: "Zip"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			csvReader, err := New(strings.NewReader("name,quantity,price,country,zip\n" + tc.row))
+			MustNoError(t, err)
+
+			csvReader.Next(&err)
+			MustNoError(t, err)
+
+			var d data
+			err = csvReader.UnmarshalLine(&d)
+			if tc.expectErr == "" {
+				MustNoError(t, err)
+				return
+			}
+
+			var parseErr *ParseError
+			if !errors.As(err, &parseErr) || !errors.Is(err, ErrValidation) || parseErr.Field != tc.expectErr {
+				t.Fatalf("Expected validation error for field %s but got %v", tc.expectErr, err)
+			}
+		})
+	}
+}
+
+func TestPatternWithComma(t *testing.T) {
+	type data struct {
+		Code string `csv:"code,required,pattern:[0-9]{1,3}"`
+	}
+
+	csvReader, err := New(strings.NewReader("code,3}\n123,x\n1234,x"))
+	MustNoError(t, err)
+
+	csvReader.Next(&err)
+	MustNoError(t, err)
+
+	var d data
+	MustNoError(t, csvReader.UnmarshalLine(&d))
+	if d.Code != "123" {
+		t.Fatalf("Expected code 123 but got %q", d.Code)
+	}
+
+	csvReader.Next(&err)
+	MustNoError(t, err)
+
+	if err := csvReader.UnmarshalLine(&d); !errors.Is(err, ErrValidation) {
+		t.Fatalf("Expected validation error but got %v", err)
+	}
+}
+
+func TestUnknownTagOption(t *testing.T) {
+	type data struct {
+		Code string `csv:"code,requried"`
+	}
+
+	csvReader, err := New(strings.NewReader("code\n123"))
+	MustNoError(t, err)
+
+	csvReader.Next(&err)
+	MustNoError(t, err)
+
+	var d data
+	MustError(t, csvReader.UnmarshalLine(&d))
+}
+
+func TestValidationTagsUnsupportedType(t *testing.T) {
+	type data struct {
+		Active bool `csv:"active,min:1"`
+	}
+
+	csvReader, err := New(strings.NewReader("active\ntrue"))
+	MustNoError(t, err)
+
+	csvReader.Next(&err)
+	MustNoError(t, err)
+
+	var d data
+	MustError(t, csvReader.UnmarshalLine(&d))
+}
+
+type periodStruct struct {
+	Start    time.Time `csv:"start,format:2006-01-02"`
+	End      time.Time `csv:"end,format:2006-01-02"`
+	Coupon   string    `csv:"coupon"`
+	Discount float64   `csv:"discount"`
+}
+
+func (p periodStruct) Validate() error {
+	if p.End.Before(p.Start) {
+		return errors.New("end must not be before start")
+	}
+	if p.Discount > 0 && p.Coupon == "" {
+		return errors.New("discount requires a coupon")
+	}
+	return nil
+}
+
+func TestValidator(t *testing.T) {
+	testCases := []struct {
+		name      string
+		row       string
+		expectErr bool
+	}{
+		{name: "Valid Data", row: "2023-12-04,2023-12-05,XMAS,0.1"},
+		{name: "End Before Start", row: "2023-12-04,2023-12-03,,0", expectErr: true},
+		{name: "Discount Without Coupon", row: "2023-12-04,2023-12-05,,0.1", expectErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			csvReader, err := New(strings.NewReader("start,end,coupon,discount\n" + tc.row))
+			MustNoError(t, err)
+
+			csvReader.Next(&err)
+			MustNoError(t, err)
+
+			var p periodStruct
+			err = csvReader.UnmarshalLine(&p)
+			if !tc.expectErr {
+				MustNoError(t, err)
+				return
+			}
+
+			var parseErr *ParseError
+			if !errors.As(err, &parseErr) || !errors.Is(err, ErrValidation) || parseErr.Line != 2 || parseErr.Record != 0 {
+				t.Fatalf("Expected validation error in line 2 but got %v", err)
+			}
+		})
+	}
+}
+
+func TestValidatorSkippedOnErrors(t *testing.T) {
+	csvReader, err := New(strings.NewReader("start,end,coupon,discount\n2023-12-04,2023-12-03,,x"), WithCollectErrors())
+	MustNoError(t, err)
+
+	csvReader.Next(&err)
+	MustNoError(t, err)
+
+	var p periodStruct
+	err = csvReader.UnmarshalLine(&p)
+	if err == nil || errors.Is(err, ErrValidation) {
+		t.Fatalf("Expected only the conversion error but got %v", err)
+	}
+}
@@ -0,0 +1,309 @@
+package vcsv
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+)
+
+// CSVWriter is a CSV writer that supports writing structs as CSV lines. It is the counterpart of CSVReader
+// and uses the same `csv` struct tags, so a file written by the CSVWriter can be read back by the CSVReader.
+// The header is written by default before the first line. Use the WithoutHeader option to disable it.
+type CSVWriter struct {
+	writer        *csv.Writer
+	header        []string
+	headerIndex   map[string]int
+	writeHeader   bool
+	headerWritten bool
+	layouts       map[reflect.Type]*writerLayout
+}
+
+// writerLayout describes where the tagged fields of a struct type are written to in a CSV line.
+type writerLayout struct {
+	header []string
+	fields []writerField
+	extras []writerExtra
+}
+
+type writerField struct {
+	taggedField
+	column int
+	elem   int // element of a slice field bound to a column range or prefix, -1 for other fields
+}
+
+// writerExtra is a map field with the extra option, whose values are written to the header columns that are not
+// bound to other fields.
+type writerExtra struct {
+	taggedField
+	columns []int
+}
+
+// NewWriter creates a new CSVWriter.
+func NewWriter(w io.Writer, options ...WriterOption) (*CSVWriter, error) {
+	if w == nil {
+		return nil, errors.New("writer must not be nil")
+	}
+
+	c := CSVWriter{
+		writer:      csv.NewWriter(w),
+		writeHeader: true,
+		layouts:     make(map[reflect.Type]*writerLayout),
+	}
+
+	for _, option := range options {
+		option(&c)
+	}
+	return &c, nil
+}
+
+// WriteHeader writes the CSV header for the given struct. The header is built from the `csv` tags of the struct fields,
+// or taken from the WithWriterHeader option if it was set.
+// This method is called automatically by MarshalLine, use it only if you want to write a header without any lines.
+func (w *CSVWriter) WriteHeader(v interface{}) error {
+	rv, err := structValue(v)
+	if err != nil {
+		return err
+	}
+
+	layout, err := w.layout(rv.Type())
+	if err != nil {
+		return err
+	}
+
+	w.headerWritten = true
+	return w.writer.Write(layout.header)
+}
+
+// MarshalLine writes the given struct as the next CSV line.
+// The struct fields should be annotated with the `csv` tag, see CSVReader.UnmarshalLine for the supported tag options.
+// The struct fields types may be any primitive type, time.Time or implement encoding.TextMarshaler.
+func (w *CSVWriter) MarshalLine(v interface{}) error {
+	rv, err := structValue(v)
+	if err != nil {
+		return err
+	}
+
+	layout, err := w.layout(rv.Type())
+	if err != nil {
+		return err
+	}
+
+	if w.writeHeader && !w.headerWritten && hasColumnNames(layout.header) {
+		w.headerWritten = true
+		if err := w.writer.Write(layout.header); err != nil {
+			return err
+		}
+	}
+
+	record := make([]string, len(layout.header))
+	for _, f := range layout.fields {
+		// fields of nil pointers to nested structs are written as empty values
+		fv, err := rv.FieldByIndexErr(f.index)
+		if err != nil {
+			continue
+		}
+		if f.elem >= 0 {
+			if f.elem >= fv.Len() {
+				continue
+			}
+			fv = fv.Index(f.elem)
+		}
+
+		value, err := formatValue(fv, f.tagOpts)
+		if err != nil {
+			return fmt.Errorf("failed to format value of type %s in field %s [%s]: %w",
+				f.structField.Type.Kind(), f.name, f.structField.Tag, err)
+		}
+		record[f.column] = value
+	}
+
+	for _, f := range layout.extras {
+		if err := writeExtra(rv, f, layout.header, record); err != nil {
+			return err
+		}
+	}
+	return w.writer.Write(record)
+}
+
+// Flush writes any buffered data to the underlying io.Writer and returns the first error that occurred while writing.
+func (w *CSVWriter) Flush() error {
+	w.writer.Flush()
+	return w.writer.Error()
+}
+
+// layout returns the cached layout of the given struct type or builds it.
+func (w *CSVWriter) layout(rt reflect.Type) (*writerLayout, error) {
+	if layout, ok := w.layouts[rt]; ok {
+		return layout, nil
+	}
+
+	layout, err := w.buildLayout(rt)
+	if err != nil {
+		return nil, err
+	}
+	w.layouts[rt] = layout
+	return layout, nil
+}
+
+// buildLayout places fields tagged with an index at that index and the elements of fields tagged with a column range
+// in these columns. Fields tagged with a column name are placed at the column of the WithWriterHeader header,
+// or at the next free column if no header was set. The elements of fields tagged with a prefix are placed in the
+// columns of the WithWriterHeader header starting with the prefix, and map fields with the extra option are only
+// written to the free columns of the WithWriterHeader header.
+func (w *CSVWriter) buildLayout(rt reflect.Type) (*writerLayout, error) {
+	layout := &writerLayout{header: append([]string(nil), w.header...)}
+	occupied := make(map[int]bool)
+
+	fields, err := cachedTaggedFields(rt)
+	if err != nil {
+		return nil, err
+	}
+
+	var named []writerField
+	for _, tf := range fields {
+		f := writerField{taggedField: tf, column: -1, elem: -1}
+		switch {
+		case tf.tagOpts.extra:
+			if w.headerIndex != nil {
+				layout.extras = append(layout.extras, writerExtra{taggedField: tf})
+			}
+			continue
+		case tf.tagOpts.indexEnd >= 0:
+			for i := tf.tagOpts.index; i <= tf.tagOpts.indexEnd; i++ {
+				occupied[i] = true
+				layout.fields = append(layout.fields, writerField{taggedField: tf, column: i, elem: i - tf.tagOpts.index})
+			}
+			continue
+		case tf.tagOpts.prefix != "":
+			if w.headerIndex == nil {
+				return nil, fmt.Errorf("field %s with prefix \"%s\" requires a header set by WithWriterHeader", tf.name, tf.tagOpts.prefix)
+			}
+			elem := 0
+			for column, name := range w.header {
+				if strings.HasPrefix(name, tf.tagOpts.prefix) {
+					occupied[column] = true
+					layout.fields = append(layout.fields, writerField{taggedField: tf, column: column, elem: elem})
+					elem++
+				}
+			}
+			if elem == 0 {
+				return nil, fmt.Errorf("no column of the header has the prefix \"%s\" of field %s", tf.tagOpts.prefix, tf.name)
+			}
+			continue
+		case tf.tagOpts.columnName != "" && w.headerIndex != nil:
+			column, ok := w.headerColumn(tf.tagOpts.columnNames)
+			if !ok {
+				return nil, fmt.Errorf("column \"%s\" of field %s is not part of the header", tf.tagOpts.columnName, tf.name)
+			}
+			f.column = column
+		case tf.tagOpts.columnName != "":
+			named = append(named, f)
+			continue
+		case tf.tagOpts.index >= 0:
+			f.column = tf.tagOpts.index
+		default:
+			continue
+		}
+
+		occupied[f.column] = true
+		layout.fields = append(layout.fields, f)
+	}
+
+	next := 0
+	for _, f := range named {
+		for occupied[next] {
+			next++
+		}
+		f.column = next
+		occupied[next] = true
+		layout.fields = append(layout.fields, f)
+	}
+
+	for _, f := range layout.fields {
+		for f.column >= len(layout.header) {
+			layout.header = append(layout.header, "")
+		}
+		if w.headerIndex == nil {
+			layout.header[f.column] = f.tagOpts.columnName
+		}
+	}
+
+	for i := range layout.extras {
+		for column := range layout.header {
+			if !occupied[column] {
+				layout.extras[i].columns = append(layout.extras[i].columns, column)
+			}
+		}
+	}
+	return layout, nil
+}
+
+// writeExtra writes the values of a map field with the extra option to the columns with the same name as the key.
+func writeExtra(rv reflect.Value, f writerExtra, header, record []string) error {
+	fv, err := rv.FieldByIndexErr(f.index)
+	if err != nil || fv.IsNil() {
+		return nil
+	}
+
+	for _, column := range f.columns {
+		value := fv.MapIndex(reflect.ValueOf(header[column]).Convert(fv.Type().Key()))
+		if value.Kind() == reflect.Interface {
+			value = value.Elem()
+		}
+		if !value.IsValid() {
+			continue
+		}
+
+		formatted, err := formatValue(value, f.tagOpts)
+		if err != nil {
+			return fmt.Errorf("failed to format value of key %s in field %s [%s]: %w", header[column], f.name, f.structField.Tag, err)
+		}
+		record[column] = formatted
+	}
+	return nil
+}
+
+// headerColumn returns the index of the first of the given column names that is part of the WithWriterHeader header.
+func (w *CSVWriter) headerColumn(names []string) (int, bool) {
+	for _, name := range names {
+		if i, ok := w.headerIndex[name]; ok {
+			return i, true
+		}
+	}
+	return -1, false
+}
+
+func hasColumnNames(header []string) bool {
+	for _, name := range header {
+		if name != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// structValue returns the struct value of v, which must be a struct or a non-nil pointer to a struct.
+// The returned value is always addressable, so methods with pointer receivers can be called on its fields.
+func structValue(v interface{}) (reflect.Value, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return reflect.Value{}, errors.New("v must be a struct or a non-nil pointer to a struct")
+		}
+		rv = rv.Elem()
+	}
+
+	if rv.Kind() != reflect.Struct {
+		return reflect.Value{}, errors.New("v must be a struct or a non-nil pointer to a struct")
+	}
+
+	if !rv.CanAddr() {
+		ptr := reflect.New(rv.Type())
+		ptr.Elem().Set(rv)
+		rv = ptr.Elem()
+	}
+	return rv, nil
+}
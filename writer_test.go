@@ -0,0 +1,315 @@
+package vcsv
+
+import (
+	"bytes"
+	"database/sql"
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type Level int
+
+func (l Level) MarshalText() ([]byte, error) {
+	return []byte([]string{"low", "high"}[l]), nil
+}
+
+func (l *Level) UnmarshalText(b []byte) error {
+	*l = map[string]Level{"low": 0, "high": 1}[string(b)]
+	return nil
+}
+
+type Severity struct {
+	Level Level
+}
+
+func (s Severity) MarshalText() ([]byte, error) {
+	return s.Level.MarshalText()
+}
+
+func (s *Severity) UnmarshalText(b []byte) error {
+	return s.Level.UnmarshalText(b)
+}
+
+type WriterTestStruct struct {
+	StringField     string     `csv:"field1"`
+	IntField        int        `csv:"field2"`
+	FloatField      float64    `csv:"field3"`
+	BoolField       bool       `csv:"field4"`
+	TimeField       time.Time  `csv:"field5,format:2006-01-02"`
+	DecimalFieldPtr *big.Float `csv:"field6"`
+	SeverityField   Severity   `csv:"field7"`
+	NoTagField      string
+}
+
+func TestMarshalLine(t *testing.T) {
+	testCases := []struct {
+		name    string
+		options []WriterOption
+		lines   []WriterTestStruct
+		expect  string
+	}{
+		{
+			name: "Valid Data",
+			lines: []WriterTestStruct{
+				{StringField: "hello", IntField: 42, FloatField: 1.5, BoolField: true, TimeField: time.Date(2023, 12, 4, 0, 0, 0, 0, time.UTC), DecimalFieldPtr: big.NewFloat(123.5), SeverityField: Severity{Level: 1}, NoTagField: "ignored"},
+				{StringField: "a,b", IntField: -1, TimeField: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)},
+			},
+			expect: "field1,field2,field3,field4,field5,field6,field7\n" +
+				"hello,42,1.5,true,2023-12-04,123.5,high\n" +
+				"\"a,b\",-1,0,false,2024-01-02,,low\n",
+		},
+		{
+			name:    "Without Header",
+			options: []WriterOption{WithoutHeader(), WithWriterSeparationChar(';')},
+			lines:   []WriterTestStruct{{StringField: "hello", IntField: 42}},
+			expect:  "hello;42;0;false;0001-01-01;;low\n",
+		},
+		{
+			name:    "Custom Header",
+			options: []WriterOption{WithWriterHeader([]string{"field2", "other", "field1", "field3", "field4", "field5", "field6", "field7"})},
+			lines:   []WriterTestStruct{{StringField: "hello", IntField: 42}},
+			expect:  "field2,other,field1,field3,field4,field5,field6,field7\n" + "42,,hello,0,false,0001-01-01,,low\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			csvWriter, err := NewWriter(buf, tc.options...)
+			MustNoError(t, err)
+
+			for _, line := range tc.lines {
+				MustNoError(t, csvWriter.MarshalLine(&line))
+			}
+			MustNoError(t, csvWriter.Flush())
+
+			if got := buf.String(); got != tc.expect {
+				t.Fatalf("Expected %q but got %q", tc.expect, got)
+			}
+		})
+	}
+}
+
+func TestMarshalLineByColumnIndex(t *testing.T) {
+	type TestStructByIndex struct {
+		StringField string `csv:"index:2"`
+		IntField    int    `csv:"index:0"`
+	}
+
+	buf := new(bytes.Buffer)
+	csvWriter, err := NewWriter(buf)
+	MustNoError(t, err)
+
+	MustNoError(t, csvWriter.MarshalLine(TestStructByIndex{StringField: "hello", IntField: 42}))
+	MustNoError(t, csvWriter.Flush())
+
+	if expect, got := "42,,hello\n", buf.String(); got != expect {
+		t.Fatalf("Expected %q but got %q", expect, got)
+	}
+}
+
+func TestWriteReadRoundTrip(t *testing.T) {
+	lines := []WriterTestStruct{
+		{StringField: "hello", IntField: 42, FloatField: 1.25, BoolField: true, TimeField: time.Date(2023, 12, 4, 0, 0, 0, 0, time.UTC), DecimalFieldPtr: big.NewFloat(123.5), SeverityField: Severity{Level: 1}},
+		{StringField: "quoted \"value\"\nwith newline", IntField: -7, FloatField: -0.5, TimeField: time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC), SeverityField: Severity{Level: 0}},
+	}
+
+	buf := new(bytes.Buffer)
+	csvWriter, err := NewWriter(buf)
+	MustNoError(t, err)
+	for _, line := range lines {
+		MustNoError(t, csvWriter.MarshalLine(line))
+	}
+	MustNoError(t, csvWriter.Flush())
+
+	csvReader, err := New(buf)
+	MustNoError(t, err)
+
+	var got []WriterTestStruct
+	for csvReader.Next(&err) {
+		var line WriterTestStruct
+		MustNoError(t, csvReader.UnmarshalLine(&line))
+		got = append(got, line)
+	}
+	MustNoError(t, err)
+
+	if len(got) != len(lines) {
+		t.Fatalf("Expected %d lines but got %d", len(lines), len(got))
+	}
+	for i := range lines {
+		if lines[i].DecimalFieldPtr != nil && lines[i].DecimalFieldPtr.Cmp(got[i].DecimalFieldPtr) != 0 {
+			t.Fatalf("Expected %v but got %v", lines[i].DecimalFieldPtr, got[i].DecimalFieldPtr)
+		}
+		lines[i].DecimalFieldPtr, got[i].DecimalFieldPtr = nil, nil
+		if ok := reflect.DeepEqual(lines[i], got[i]); !ok {
+			t.Fatalf("Expected %+v but got %+v", lines[i], got[i])
+		}
+	}
+}
+
+func TestWriteReadRoundTripDefaultTimeFormat(t *testing.T) {
+	type data struct {
+		At time.Time `csv:"at"`
+	}
+	line := data{At: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)}
+
+	buf := new(bytes.Buffer)
+	csvWriter, err := NewWriter(buf)
+	MustNoError(t, err)
+	MustNoError(t, csvWriter.MarshalLine(line))
+	MustNoError(t, csvWriter.Flush())
+
+	if expect := "at\n2024-01-02T03:04:05Z\n"; buf.String() != expect {
+		t.Fatalf("Expected %q but got %q", expect, buf.String())
+	}
+
+	csvReader, err := New(buf)
+	MustNoError(t, err)
+
+	csvReader.Next(&err)
+	MustNoError(t, err)
+
+	var got data
+	MustNoError(t, csvReader.UnmarshalLine(&got))
+	if !got.At.Equal(line.At) {
+		t.Fatalf("Expected %v but got %v", line.At, got.At)
+	}
+}
+
+func TestMarshalLineNullableFields(t *testing.T) {
+	answer := 42
+	date := time.Date(2023, 12, 4, 0, 0, 0, 0, time.UTC)
+	lines := []NullableStruct{
+		{},
+		{IntPtr: &answer, TimePtr: &date, NullString: sql.NullString{String: "hello", Valid: true}, NullTime: sql.NullTime{Time: date, Valid: true}, NullGeneric: sql.Null[int]{V: 7, Valid: true}},
+	}
+
+	buf := new(bytes.Buffer)
+	csvWriter, err := NewWriter(buf)
+	MustNoError(t, err)
+	for _, line := range lines {
+		MustNoError(t, csvWriter.MarshalLine(line))
+	}
+	MustNoError(t, csvWriter.Flush())
+
+	expect := nullableCSV(",,,,,,,,,,\n42,,,,2023-12-04,hello,,,,2023-12-04,7\n")
+	if got := buf.String(); got != expect {
+		t.Fatalf("Expected %q but got %q", expect, got)
+	}
+}
+
+func TestMarshalLineNestedStructs(t *testing.T) {
+	buf := new(bytes.Buffer)
+	csvWriter, err := NewWriter(buf)
+	MustNoError(t, err)
+
+	MustNoError(t, csvWriter.MarshalLine(nestedOrder{
+		ID:         1,
+		Billing:    nestedAddress{Street: "Main St", City: "Köln"},
+		NestedMeta: NestedMeta{Source: "web", Contact: nestedContact{Email: "alice@example.com"}},
+	}))
+	MustNoError(t, csvWriter.Flush())
+
+	expect := "id,billing_street,billing_city,shipping_street,shipping_city,source,contact_email\n" +
+		"1,Main St,Köln,,,web,alice@example.com\n"
+	if got := buf.String(); got != expect {
+		t.Fatalf("Expected %q but got %q", expect, got)
+	}
+}
+
+func TestMarshalLineSliceFields(t *testing.T) {
+	one := 1
+	lines := []sliceStruct{
+		{},
+		{
+			Tags:    []string{"a", "b"},
+			Numbers: []int{1, 2, 3},
+			Scores:  [3]float64{1.5, 2},
+			Dates:   []time.Time{time.Date(2023, 12, 4, 0, 0, 0, 0, time.UTC)},
+			Ptrs:    []*int{&one, nil},
+		},
+	}
+
+	buf := new(bytes.Buffer)
+	csvWriter, err := NewWriter(buf)
+	MustNoError(t, err)
+	for _, line := range lines {
+		MustNoError(t, csvWriter.MarshalLine(line))
+	}
+	MustNoError(t, csvWriter.Flush())
+
+	expect := "tags,numbers,scores,dates,ptrs\n" + ",,0;0;0,,\n" + "a|b,1;2;3,1.5;2;0,2023-12-04,1;\n"
+	if got := buf.String(); got != expect {
+		t.Fatalf("Expected %q but got %q", expect, got)
+	}
+}
+
+func TestMarshalLineColumnRange(t *testing.T) {
+	type data struct {
+		Name  string `csv:"index:0"`
+		Sales []int  `csv:"index:1-3"`
+	}
+
+	buf := new(bytes.Buffer)
+	csvWriter, err := NewWriter(buf)
+	MustNoError(t, err)
+
+	MustNoError(t, csvWriter.MarshalLine(data{Name: "alice", Sales: []int{1, 2}}))
+	MustNoError(t, csvWriter.Flush())
+
+	if expect, got := "alice,1,2,\n", buf.String(); got != expect {
+		t.Fatalf("Expected %q but got %q", expect, got)
+	}
+}
+
+func TestMarshalLinePrefix(t *testing.T) {
+	type data struct {
+		Name  string `csv:"name"`
+		Weeks []int  `csv:"prefix:week_"`
+	}
+
+	buf := new(bytes.Buffer)
+	csvWriter, err := NewWriter(buf, WithWriterHeader([]string{"week_1", "name", "week_2", "week_3"}))
+	MustNoError(t, err)
+
+	MustNoError(t, csvWriter.MarshalLine(data{Name: "alice", Weeks: []int{1, 2}}))
+	MustNoError(t, csvWriter.Flush())
+
+	if expect, got := "week_1,name,week_2,week_3\n1,alice,2,\n", buf.String(); got != expect {
+		t.Fatalf("Expected %q but got %q", expect, got)
+	}
+
+	for _, header := range [][]string{nil, {"name", "month_1"}} {
+		var opts []WriterOption
+		if header != nil {
+			opts = append(opts, WithWriterHeader(header))
+		}
+		csvWriter, err := NewWriter(new(bytes.Buffer), opts...)
+		MustNoError(t, err)
+
+		if err := csvWriter.MarshalLine(data{Name: "alice"}); err == nil {
+			t.Fatalf("Expected error for header %q", header)
+		}
+	}
+}
+
+func TestMarshalLineExtraField(t *testing.T) {
+	type data struct {
+		Name  string         `csv:"name"`
+		Extra map[string]any `csv:",extra"`
+	}
+
+	buf := new(bytes.Buffer)
+	csvWriter, err := NewWriter(buf, WithWriterHeader([]string{"zip", "name", "comment"}))
+	MustNoError(t, err)
+
+	MustNoError(t, csvWriter.MarshalLine(data{Name: "alice", Extra: map[string]any{"zip": 50667, "other": "ignored"}}))
+	MustNoError(t, csvWriter.MarshalLine(data{Name: "bob"}))
+	MustNoError(t, csvWriter.Flush())
+
+	if expect, got := "zip,name,comment\n50667,alice,\n,bob,\n", buf.String(); got != expect {
+		t.Fatalf("Expected %q but got %q", expect, got)
+	}
+}